@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// batteryInterval is how often the battery is re-read. Its charge changes
+// slowly, and on macOS reading it runs pmset.
+const batteryInterval = 10 * time.Second
+
+// batteryLow is the charge, in percent, below which a discharging battery
+// is shown in the critical color
+const batteryLow = 20
+
+// batteryState is whether the battery is charging, and whether it's full
+type batteryState int
+
+const (
+	batteryUnknown batteryState = iota
+	batteryCharging
+	batteryDischarging
+	batteryFull
+	batteryNotCharging // On AC power but held below full, as some laptops do to spare the battery
+)
+
+// batteryStatus is a reading of the system's batteries, combined when there
+// is more than one
+type batteryStatus struct {
+	Percent   float64
+	State     batteryState
+	Remaining time.Duration // Until empty when discharging, or full when charging; 0 when unknown
+}
+
+// Battery is the header's battery field. Whether there is a battery is
+// decided once at startup; a desktop without one shows no field and is
+// never read again.
+type Battery struct {
+	Present bool
+	Status  batteryStatus
+	read    time.Time // When Status was last read
+}
+
+// detectBattery looks for the system's battery and takes a first reading
+func detectBattery() *Battery {
+	b := &Battery{read: time.Now()}
+	b.Status, b.Present = readBattery()
+	return b
+}
+
+// Update re-reads the battery once batteryInterval has passed. A failed
+// reading keeps the last one rather than logging every refresh.
+func (b *Battery) Update() {
+	if !b.Present || time.Since(b.read) < batteryInterval {
+		return
+	}
+	b.read = time.Now()
+	if status, ok := readBattery(); ok {
+		b.Status = status
+	}
+}
+
+// Low reports whether the battery is discharging below batteryLow
+func (b *Battery) Low() bool {
+	return b.Status.State == batteryDischarging && b.Status.Percent < batteryLow
+}
+
+// String describes the battery for the header, e.g. "Bat: 84% discharging,
+// 2h 10m left" or "Bat: 100% full"
+func (b *Battery) String() string {
+	s := b.Status
+	text := fmt.Sprintf("Bat: %.0f%%", s.Percent)
+	switch s.State {
+	case batteryCharging:
+		text += " charging"
+		if s.Remaining > 0 {
+			text += ", " + formatBatteryTime(s.Remaining) + " to full"
+		}
+	case batteryDischarging:
+		text += " discharging"
+		if s.Remaining > 0 {
+			text += ", " + formatBatteryTime(s.Remaining) + " left"
+		}
+	case batteryFull:
+		text += " full"
+	case batteryNotCharging:
+		text += " not charging"
+	}
+	return text
+}
+
+// formatBatteryTime formats the time a battery has left, e.g. "2h 10m" or
+// "45m"
+func formatBatteryTime(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pmsetTimeout bounds how long pmset may take to report the battery, since
+// it runs on the UI goroutine
+const pmsetTimeout = 300 * time.Millisecond
+
+// readBattery reads the internal battery's charge and state from pmset,
+// whose output is the same information IOKit gives without needing cgo. It
+// reports false when there is no battery, as on desktop Macs.
+func readBattery() (batteryStatus, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), pmsetTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "pmset", "-g", "batt").Output()
+	if err != nil {
+		return batteryStatus{}, false
+	}
+	return parsePmsetBattery(string(out))
+}
+
+// parsePmsetBattery reads the internal battery's line of pmset -g batt, e.g.
+// " -InternalBattery-0 (id=4653155)	84%; discharging; 3:42 remaining present: true"
+func parsePmsetBattery(out string) (batteryStatus, bool) {
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.Contains(line, "InternalBattery") {
+			continue
+		}
+		_, details, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Split(details, ";")
+		if len(fields) < 2 {
+			continue
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(fields[0]), "%"), 64)
+		if err != nil {
+			continue
+		}
+
+		status := batteryStatus{Percent: percent}
+		switch strings.TrimSpace(fields[1]) {
+		case "charging", "finishing charge":
+			status.State = batteryCharging
+		case "discharging":
+			status.State = batteryDischarging
+		case "charged":
+			status.State = batteryFull
+		case "AC attached":
+			status.State = batteryNotCharging
+		}
+
+		// The estimate is given as e.g. "3:42 remaining", or "(no estimate)"
+		// while it's still being worked out
+		if len(fields) > 2 {
+			if estimate := strings.Fields(fields[2]); len(estimate) > 1 && estimate[1] == "remaining" {
+				if hours, minutes, ok := strings.Cut(estimate[0], ":"); ok {
+					h, errH := strconv.Atoi(hours)
+					m, errM := strconv.Atoi(minutes)
+					if errH == nil && errM == nil {
+						status.Remaining = time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+					}
+				}
+			}
+		}
+		return status, true
+	}
+	return batteryStatus{}, false
+}
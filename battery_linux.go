@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// powerSupplyDir is where the kernel lists the power supplies
+const powerSupplyDir = "/sys/class/power_supply"
+
+// batteryDirs returns the power supply directories of the system's
+// batteries, found once since batteries aren't added while running.
+// Batteries with device scope, such as a wireless mouse's, are left out.
+var batteryDirs = sync.OnceValue(func() []string {
+	entries, err := os.ReadDir(powerSupplyDir)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, entry := range entries {
+		dir := filepath.Join(powerSupplyDir, entry.Name())
+		if readSysfsString(dir, "type") != "Battery" || readSysfsString(dir, "scope") == "Device" {
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+})
+
+// readBattery reads the batteries' charge and state from sysfs, combining
+// them by the energy they hold when there is more than one. It reports
+// false when there is no battery.
+func readBattery() (batteryStatus, bool) {
+	dirs := batteryDirs()
+	if len(dirs) == 0 {
+		return batteryStatus{}, false
+	}
+
+	var status batteryStatus
+	var now, full, rate, capacity float64
+	read := 0
+	for _, dir := range dirs {
+		percent, err := strconv.ParseFloat(readSysfsString(dir, "capacity"), 64)
+		if err != nil {
+			continue
+		}
+		read++
+		capacity += percent
+
+		switch readSysfsString(dir, "status") {
+		case "Charging":
+			status.State = batteryCharging
+		case "Discharging":
+			status.State = batteryDischarging
+		case "Full":
+			if status.State == batteryUnknown {
+				status.State = batteryFull
+			}
+		case "Not charging":
+			if status.State == batteryUnknown {
+				status.State = batteryNotCharging
+			}
+		}
+
+		// Batteries report energy in µWh and power in µW, or charge in µAh
+		// and current in µA; either gives the time remaining, since the
+		// voltage cancels out
+		nowName, fullName, rateName := "energy_now", "energy_full", "power_now"
+		if readSysfsString(dir, nowName) == "" {
+			nowName, fullName, rateName = "charge_now", "charge_full", "current_now"
+		}
+		level, levelErr := strconv.ParseFloat(readSysfsString(dir, nowName), 64)
+		levelFull, fullErr := strconv.ParseFloat(readSysfsString(dir, fullName), 64)
+		if levelErr == nil && fullErr == nil {
+			now += level
+			full += levelFull
+		}
+		if r, err := strconv.ParseFloat(readSysfsString(dir, rateName), 64); err == nil {
+			// Some batteries report the current negated while discharging
+			rate += abs(r)
+		}
+	}
+	if read == 0 {
+		return batteryStatus{}, false
+	}
+
+	status.Percent = capacity / float64(read)
+	if full > 0 && read > 1 {
+		status.Percent = now / full * 100
+	}
+	if rate > 0 {
+		var hours float64
+		switch status.State {
+		case batteryDischarging:
+			hours = now / rate
+		case batteryCharging:
+			hours = (full - now) / rate
+		}
+		status.Remaining = time.Duration(hours * float64(time.Hour))
+	}
+	return status, true
+}
+
+// readSysfsString returns the trimmed contents of a sysfs attribute, or ""
+// when it can't be read
+func readSysfsString(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
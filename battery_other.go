@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package main
+
+// readBattery reports no battery, since reading one is only supported on
+// Linux and macOS
+func readBattery() (batteryStatus, bool) {
+	return batteryStatus{}, false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// errNoClipboard is returned when no way of reaching a clipboard was found
+var errNoClipboard = errors.New("no clipboard available")
+
+// clipboardCommands lists the clipboard helpers to try on this platform, in
+// order of preference. Each reads the text to copy from stdin.
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip.exe"}}
+	}
+
+	var commands [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		commands = append(commands, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		commands = append(commands,
+			[]string{"xclip", "-selection", "clipboard"},
+			[]string{"xsel", "--clipboard", "--input"},
+		)
+	}
+	// Under WSL the Windows clipboard is reachable through clip.exe
+	return append(commands, []string{"clip.exe"})
+}
+
+// copyToClipboard puts text on the system clipboard using the first helper
+// that is installed. In SSH sessions without one, the text is sent to the
+// local terminal with an OSC 52 escape sequence instead, which most modern
+// terminals honour.
+func copyToClipboard(text string) error {
+	for _, args := range clipboardCommands() {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		_, err := fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+		return err
+	}
+	return errNoClipboard
+}
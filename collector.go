@@ -0,0 +1,557 @@
+package main
+
+import (
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// CollectOptions controls what a collection pass gathers. It is passed by
+// value with each request so the collector never reads UI state.
+type CollectOptions struct {
+	ShowKernel      bool // Include kernel threads
+	ShowIO          bool // Gather per-process disk I/O rates
+	ShowFDs         bool // Count open file descriptors, which means listing /proc/PID/fd
+	ShowOOM         bool // Read OOM killer scores
+	ShowMemory      bool // Read shared and swapped memory
+	ShowNice        bool // Read nice values
+	ShowCtxSwitches bool // Gather context switch rates
+	ShowContainer   bool // Resolve the container each process runs in
+
+	// Limit, when positive, restricts the expensive fields (command line,
+	// threads, CPU time, and the optional columns) to the first Limit
+	// processes in SortKey order, plus those in Keep. Zero gathers every
+	// field for every process.
+	Limit         int
+	SortKey       SortKey
+	SortAscending bool
+	Keep          map[int32]bool // PIDs that always get every field, e.g. rows on screen
+}
+
+// processSnapshot is the result of one collection pass
+type processSnapshot struct {
+	Processes []ProcessInfo
+	Partial   int // Processes with fields that failed to read and were filled with placeholders
+	Err       error
+}
+
+// ProcessCollector walks the process table on its own goroutine so a slow
+// pass never stalls gauge animation or key handling. Completed snapshots are
+// published on Snapshots for the UI loop to swap in.
+type ProcessCollector struct {
+	Snapshots chan processSnapshot    // Completed collection passes
+	requests  chan CollectOptions     // Collection requests from the UI loop
+	done      chan struct{}           // Closed by Stop to end the goroutine
+	wg        sync.WaitGroup          // Tracks the running goroutine
+	cache     map[int32]*processEntry // Per-PID handles and static fields reused across passes
+}
+
+// processEntry caches a process handle and the previous pass's CPU time, so
+// CPU% is interval-based, along with fields that are resolved once per
+// process lifetime
+type processEntry struct {
+	proc   *process.Process
+	user   string // Owning user, resolved on first use since lookups are slow
+	uid    int32  // Owning real UID, or -1 when unknown
+	kernel bool   // Whether this is a kernel thread, decided when first seen
+
+	created time.Time // Start time, which never changes so it is read once
+	started int64     // Start time from processStat, to spot a reused PID
+
+	// Command line, cached once the process has settled. Entries are rebuilt
+	// when their PID is reused, so this is keyed by (PID, start time).
+	cmdline     string
+	cmdlineRead bool
+
+	// Nice value, read on first use. It only changes if the process is
+	// reniced, so clear niceRead to have it read again.
+	nice     int32
+	niceRead bool
+
+	container     string // Short container ID, or "" when not containerized
+	containerRead bool   // Whether container has been resolved
+
+	lastIO     *process.IOCountersStat // I/O counters from the previous pass, for rates
+	lastIOTime time.Time               // When lastIO was read
+	cpuTime    float64                 // Cumulative CPU seconds from the previous pass
+	cpuTimeAt  time.Time               // When cpuTime was read, zero before the first pass
+
+	lastCtx     *process.NumCtxSwitchesStat // Context switch counts from the previous pass, for rates
+	lastCtxTime time.Time                   // When lastCtx was read
+}
+
+func NewProcessCollector() *ProcessCollector {
+	return &ProcessCollector{
+		Snapshots: make(chan processSnapshot, 1),
+		requests:  make(chan CollectOptions),
+		done:      make(chan struct{}),
+		cache:     make(map[int32]*processEntry),
+	}
+}
+
+// Start launches the collector goroutine
+func (c *ProcessCollector) Start() {
+	c.wg.Add(1)
+	go c.run()
+}
+
+// Stop ends the collector goroutine and waits for it to exit
+func (c *ProcessCollector) Stop() {
+	close(c.done)
+	c.wg.Wait()
+}
+
+// Request asks for a collection pass. If the previous pass is still running
+// the request is dropped rather than queued, so a slow pass never piles up
+// behind the ticker. It reports whether the request was accepted.
+func (c *ProcessCollector) Request(opts CollectOptions) bool {
+	select {
+	case c.requests <- opts:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *ProcessCollector) run() {
+	defer c.wg.Done()
+	for {
+		select {
+		case <-c.done:
+			return
+		case opts := <-c.requests:
+			processes, partial, err := c.collect(opts)
+			snap := processSnapshot{Processes: processes, Partial: partial, Err: err}
+
+			// Replace any snapshot the UI has not picked up yet
+			select {
+			case <-c.Snapshots:
+			default:
+			}
+			select {
+			case c.Snapshots <- snap:
+			case <-c.done:
+				return
+			}
+		}
+	}
+}
+
+// collect runs one collection pass. Alongside the processes it returns how
+// many of them had fields that could not be read.
+func (c *ProcessCollector) collect(opts CollectOptions) ([]ProcessInfo, int, error) {
+	pids, err := process.Pids()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Read total memory once per pass rather than once per process
+	var totalMem uint64
+	if vm, err := mem.VirtualMemory(); err == nil {
+		totalMem = vm.Total
+	}
+
+	// First pass: the fields every process needs for sorting, filtering by
+	// user, and the zombie count
+	type candidate struct {
+		entry *processEntry
+		info  ProcessInfo
+	}
+	candidates := make([]candidate, 0, len(pids))
+	alive := make(map[int32]bool, len(pids))
+	partial := 0
+	for _, pid := range pids {
+		// A stat that fails to read is passed on as nil, and the row is kept
+		// below if the process is still running
+		var stat *processStat
+		if s, err := readProcessStat(pid); err == nil {
+			stat = &s
+		}
+		entry, err := c.cachedProcess(pid, stat)
+		if err != nil {
+			continue
+		}
+		alive[pid] = true
+
+		if entry.kernel && !opts.ShowKernel {
+			continue
+		}
+
+		info, complete := getProcessInfo(entry, stat, totalMem)
+		if !complete {
+			// Only drop the row once the process is really gone, so brief
+			// permission hiccups don't make rows blink in and out
+			if running, err := entry.proc.IsRunning(); err == nil && !running {
+				continue
+			}
+			partial++
+		}
+		candidates = append(candidates, candidate{entry, info})
+	}
+
+	// Evict entries for processes that have exited
+	for pid := range c.cache {
+		if !alive[pid] {
+			delete(c.cache, pid)
+		}
+	}
+
+	// Second pass: the expensive fields, only for processes that can be on
+	// screen when a limit is given
+	detailed := len(candidates)
+	if opts.Limit > 0 && opts.Limit < len(candidates) {
+		sort.Slice(candidates, func(i, j int) bool {
+			a, b := candidates[i].info, candidates[j].info
+			if c := compareProcesses(a, b, opts.SortKey); c != 0 {
+				if opts.SortAscending {
+					return c < 0
+				}
+				return c > 0
+			}
+			return a.PID < b.PID
+		})
+		detailed = opts.Limit
+	}
+	processes := make([]ProcessInfo, 0, len(candidates))
+	for i, cand := range candidates {
+		if i < detailed || opts.Keep[cand.info.PID] {
+			addProcessDetails(cand.entry, &cand.info, opts)
+		} else {
+			skipProcessDetails(cand.entry, &cand.info)
+		}
+		processes = append(processes, cand.info)
+	}
+	return processes, partial, nil
+}
+
+// cachedProcess returns the cache entry for pid, creating it on first sight.
+// stat is the process's stat from this pass, which tells whether the PID was
+// reused since the previous one. A reused PID gets a fresh entry, so nothing
+// read for the old process carries over to the new one. With a nil stat the
+// cached entry is kept.
+func (c *ProcessCollector) cachedProcess(pid int32, stat *processStat) (*processEntry, error) {
+	if entry, ok := c.cache[pid]; ok && (stat == nil || !entry.reusedBy(*stat)) {
+		return entry, nil
+	}
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+	entry := &processEntry{proc: p, kernel: isKernelThread(p), uid: -1}
+	if stat != nil {
+		entry.started = stat.Started
+	}
+	if ms, err := p.CreateTime(); err == nil {
+		entry.created = time.UnixMilli(ms)
+	}
+	if uids, err := p.Uids(); err == nil && len(uids) > 0 {
+		entry.uid = uids[0]
+	}
+	c.cache[pid] = entry
+	return entry, nil
+}
+
+// reusedBy reports whether stat, read this pass for the entry's PID, belongs
+// to a different process than the one the entry was built for. On Linux the
+// stat carries the start time, so it is compared directly. Elsewhere reading
+// the start time costs a call of its own, so it is only checked when the CPU
+// time went backwards, which a single process's never does.
+func (e *processEntry) reusedBy(stat processStat) bool {
+	if stat.Started != 0 || e.started != 0 {
+		return stat.Started != e.started
+	}
+	if e.cpuTimeAt.IsZero() || stat.CPU >= e.cpuTime {
+		return false
+	}
+	// The handle caches its start time, so read it through a new one
+	ms, err := (&process.Process{Pid: e.proc.Pid}).CreateTime()
+	return err == nil && !time.UnixMilli(ms).Equal(e.created)
+}
+
+// cpuPercent records stat's CPU time and returns the CPU% used since the
+// previous pass. The first sample for a new process is 0.
+func (e *processEntry) cpuPercent(stat processStat, now time.Time) float64 {
+	prev, prevAt := e.cpuTime, e.cpuTimeAt
+	e.cpuTime, e.cpuTimeAt = stat.CPU, now
+	if prevAt.IsZero() || stat.CPU < prev {
+		return 0
+	}
+	elapsed := now.Sub(prevAt).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return 100 * (stat.CPU - prev) / elapsed
+}
+
+// isKernelThread reports whether p looks like a Linux kernel thread: no
+// command line and either kthreadd itself (PID 2) or one of its children
+func isKernelThread(p *process.Process) bool {
+	if cmd, err := p.Cmdline(); err != nil || cmd != "" {
+		return false
+	}
+	if p.Pid == 2 {
+		return true
+	}
+	ppid, err := p.Ppid()
+	return err == nil && ppid == 2
+}
+
+// username resolves the owning user once, falling back to the numeric UID
+// when the name lookup fails (common for processes in containers)
+func (e *processEntry) username() string {
+	if e.user != "" {
+		return e.user
+	}
+	e.user = "-"
+	if name, err := e.proc.Username(); err == nil {
+		e.user = name
+	} else if e.uid >= 0 {
+		e.user = strconv.Itoa(int(e.uid))
+	}
+	return e.user
+}
+
+// cmdlineSettle is how old a process must be before its command line is
+// cached. A freshly forked child still carries its parent's command line
+// until it calls exec, so young processes are re-read every pass.
+const cmdlineSettle = 2 * time.Second
+
+// commandLine returns the process's command line, reading it only until the
+// process has settled. name stands in when the command line can't be read.
+func (e *processEntry) commandLine(name string) string {
+	if e.cmdlineRead {
+		return e.cmdline
+	}
+	cmd, err := e.proc.Cmdline()
+	if err != nil {
+		cmd = name
+	}
+	if err == nil && !e.created.IsZero() && time.Since(e.created) >= cmdlineSettle {
+		e.cmdline, e.cmdlineRead = cmd, true
+	}
+	return cmd
+}
+
+// niceValue returns the process's nice value, reading it on first use. A
+// value that can't be read is reported as niceUnknown and not retried.
+func (e *processEntry) niceValue() int32 {
+	if !e.niceRead {
+		e.niceRead = true
+		e.nice = niceUnknown
+		if nice, err := e.proc.Nice(); err == nil {
+			e.nice = nice
+			if runtime.GOOS == "linux" {
+				// gopsutil returns the raw getpriority syscall result,
+				// which Linux reports as 20 - nice to keep it in 1..40.
+				// It ignores the syscall's error and leaves 0, so 0
+				// means the value couldn't be read.
+				e.nice = niceUnknown
+				if nice > 0 {
+					e.nice = 20 - nice
+				}
+			}
+		}
+	}
+	return e.nice
+}
+
+// containerID returns the process's container, resolving it on first use.
+// A process can't move between containers, so it is never re-read.
+func (e *processEntry) containerID() string {
+	if !e.containerRead {
+		e.containerRead = true
+		e.container = processContainer(e.proc.Pid)
+	}
+	return e.container
+}
+
+// ctxSwitchRate diffs the process's voluntary and involuntary context
+// switch counts against the previous pass and returns switches/sec. Like
+// ioRates it reports -1 for the first sample and on platforms without the
+// counters.
+func (e *processEntry) ctxSwitchRate() float64 {
+	ctx, err := e.proc.NumCtxSwitches()
+	if err != nil {
+		e.lastCtx = nil
+		return -1
+	}
+	now := time.Now()
+	prev, prevTime := e.lastCtx, e.lastCtxTime
+	e.lastCtx, e.lastCtxTime = ctx, now
+
+	elapsed := now.Sub(prevTime).Seconds()
+	if prev == nil || elapsed <= 0 {
+		return -1
+	}
+	switches := ctx.Voluntary + ctx.Involuntary - prev.Voluntary - prev.Involuntary
+	if switches < 0 {
+		return -1
+	}
+	return float64(switches) / elapsed
+}
+
+// ioRates diffs the process's I/O counters against the previous pass and
+// returns read and write bytes/sec. Unsupported or permission-denied
+// counters, and the first sample, report -1.
+func (e *processEntry) ioRates() (float64, float64) {
+	io, err := e.proc.IOCounters()
+	if err != nil {
+		e.lastIO = nil
+		return -1, -1
+	}
+	now := time.Now()
+	prev, prevTime := e.lastIO, e.lastIOTime
+	e.lastIO, e.lastIOTime = io, now
+
+	elapsed := now.Sub(prevTime).Seconds()
+	if prev == nil || elapsed <= 0 || io.ReadBytes < prev.ReadBytes || io.WriteBytes < prev.WriteBytes {
+		return -1, -1
+	}
+	return float64(io.ReadBytes-prev.ReadBytes) / elapsed, float64(io.WriteBytes-prev.WriteBytes) / elapsed
+}
+
+// fdLimitWarning is the fraction of the soft descriptor limit at which a
+// process is flagged as close to running out
+const fdLimitWarning = 0.8
+
+// openFDs counts p's open file descriptors and reports whether the count is
+// within fdLimitWarning of its soft RLIMIT_NOFILE. The count is -1 when the
+// descriptors can't be read, typically for other users' processes.
+func openFDs(p *process.Process) (int32, bool) {
+	fds, err := p.NumFDs()
+	if err != nil {
+		return -1, false
+	}
+	// The count is already known, so skip gathering usage for every limit
+	limits, err := p.RlimitUsage(false)
+	if err != nil {
+		return fds, false
+	}
+	for _, limit := range limits {
+		if limit.Resource == process.RLIMIT_NOFILE && limit.Soft > 0 {
+			return fds, float64(fds) >= float64(limit.Soft)*fdLimitWarning
+		}
+	}
+	return fds, false
+}
+
+// getProcessInfo reads the fields every process needs, taking CPU time and
+// parent from stat. Fields that fail to read, including a nil stat, are left
+// as "-" or 0 rather than failing the whole row; complete reports whether
+// everything was read.
+func getProcessInfo(entry *processEntry, stat *processStat, totalMem uint64) (ProcessInfo, bool) {
+	p := entry.proc
+	complete := true
+	name, err := p.Name()
+	if err != nil {
+		name, complete = "-", false
+	}
+
+	// Measured against the previous pass, so the first sample for a new
+	// process is always 0
+	cpu, ppid := 0.0, int32(0)
+	if stat != nil {
+		cpu, ppid = entry.cpuPercent(*stat, time.Now()), stat.PPID
+	} else {
+		complete = false
+	}
+
+	var rss uint64
+	if memInfo, err := p.MemoryInfo(); err == nil {
+		rss = memInfo.RSS
+	} else {
+		complete = false
+	}
+	memPercent := 0.0
+	if totalMem > 0 {
+		memPercent = 100 * float64(rss) / float64(totalMem)
+	}
+
+	status := ""
+	if s, err := p.Status(); err == nil && len(s) > 0 {
+		status = s[0]
+	}
+
+	return ProcessInfo{
+		PID:     p.Pid,
+		PPID:    ppid,
+		User:    entry.username(),
+		UID:     entry.uid,
+		Name:    name,
+		CPU:     cpu,
+		Memory:  memPercent,
+		RSS:     rss,
+		Status:  status,
+		Created: entry.created,
+	}, complete
+}
+
+// addProcessDetails fills in the fields that are too expensive to read for
+// processes that won't be shown: the command line, thread count, CPU time,
+// and whichever optional columns opts asks for
+func addProcessDetails(entry *processEntry, info *ProcessInfo, opts CollectOptions) {
+	p := entry.proc
+	info.Command = entry.commandLine(info.Name)
+
+	info.Threads = -1
+	if threads, err := p.NumThreads(); err == nil {
+		info.Threads = threads
+	}
+
+	info.CPUTime = entry.cpuTime
+
+	info.ReadBPS, info.WriteBPS = -1, -1
+	if opts.ShowIO {
+		info.ReadBPS, info.WriteBPS = entry.ioRates()
+	} else {
+		// Drop stale counters so re-enabling doesn't average over the gap
+		entry.lastIO = nil
+	}
+	info.Nice = niceUnknown
+	if opts.ShowNice {
+		info.Nice = entry.niceValue()
+	}
+	info.CtxSwitchRate = -1
+	if opts.ShowCtxSwitches {
+		info.CtxSwitchRate = entry.ctxSwitchRate()
+	} else {
+		entry.lastCtx = nil
+	}
+	if opts.ShowContainer {
+		info.Container = entry.containerID()
+	}
+	info.OOMScore = -1
+	if opts.ShowOOM {
+		// Re-read every pass since the score follows memory usage
+		info.OOMScore = processOOMScore(p.Pid)
+	}
+	info.Shared, info.Swap = -1, -1
+	if opts.ShowMemory {
+		info.Shared, info.Swap = processSharedSwap(p.Pid)
+	}
+	info.FDs = -1
+	if opts.ShowFDs {
+		info.FDs, info.FDsNear = openFDs(p)
+	}
+}
+
+// skipProcessDetails marks the expensive fields of a process that won't be
+// shown as unknown. Rate counters are dropped so a process that comes back
+// into view starts a fresh sample instead of averaging over the gap.
+func skipProcessDetails(entry *processEntry, info *ProcessInfo) {
+	info.Command = info.Name
+	info.Threads = -1
+	info.CPUTime = entry.cpuTime
+	info.ReadBPS, info.WriteBPS = -1, -1
+	info.Nice = niceUnknown
+	info.CtxSwitchRate = -1
+	info.OOMScore = -1
+	info.Shared, info.Swap = -1, -1
+	info.FDs = -1
+	entry.lastIO, entry.lastCtx = nil, nil
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// TestCollectReusesHandles checks that a process keeps the same entry
+// across passes, since CPU% is measured against the entry's previous pass
+func TestCollectReusesHandles(t *testing.T) {
+	c := NewProcessCollector()
+	pid := int32(os.Getpid())
+
+	if _, _, err := c.collect(CollectOptions{}); err != nil {
+		t.Fatalf("first pass: %v", err)
+	}
+	first, ok := c.cache[pid]
+	if !ok {
+		t.Fatalf("PID %d not cached after the first pass", pid)
+	}
+
+	if _, _, err := c.collect(CollectOptions{}); err != nil {
+		t.Fatalf("second pass: %v", err)
+	}
+	second, ok := c.cache[pid]
+	if !ok {
+		t.Fatalf("PID %d not cached after the second pass", pid)
+	}
+	if second != first || second.proc != first.proc {
+		t.Errorf("handle for PID %d was replaced between passes", pid)
+	}
+}
+
+// TestCachedProcessReusedPID checks that an entry left by an earlier process
+// with the same PID is replaced rather than reused
+func TestCachedProcessReusedPID(t *testing.T) {
+	c := NewProcessCollector()
+	pid := int32(os.Getpid())
+	stale := &processEntry{
+		proc:        &process.Process{Pid: pid},
+		user:        "someone",
+		uid:         12345,
+		kernel:      true,
+		created:     time.Now().Add(-time.Hour),
+		started:     -1,
+		cmdline:     "old command",
+		cmdlineRead: true,
+		nice:        19,
+		niceRead:    true,
+	}
+	c.cache[pid] = stale
+
+	stat, err := readProcessStat(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := c.cachedProcess(pid, &stat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == stale || entry.proc == stale.proc {
+		t.Fatal("entry for a reused PID was kept")
+	}
+	if entry.kernel || entry.cmdlineRead || entry.niceRead || entry.user != "" {
+		t.Errorf("fields carried over from the old process: %+v", entry)
+	}
+	if entry.created.Equal(stale.created) {
+		t.Errorf("start time carried over from the old process")
+	}
+
+	// The same process seen again keeps its entry
+	again, err := c.cachedProcess(pid, &stat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != entry {
+		t.Error("entry was replaced for the same process")
+	}
+}
+
+// TestReusedByWithoutStartTime checks the fallback for platforms whose stat
+// has no start time: only CPU time going backwards triggers a check
+func TestReusedByWithoutStartTime(t *testing.T) {
+	self, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := self.CreateTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		created time.Time
+		cpu     float64
+		want    bool
+	}{
+		{"cpu went up", time.Now().Add(-time.Hour), 20, false},
+		{"cpu went back, same start", time.UnixMilli(ms), 5, false},
+		{"cpu went back, new start", time.Now().Add(-time.Hour), 5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &processEntry{proc: self, created: tt.created, cpuTime: 10, cpuTimeAt: time.Now()}
+			if got := entry.reusedBy(processStat{CPU: tt.cpu}); got != tt.want {
+				t.Errorf("reusedBy = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// benchProcesses is how many processes the collection benchmarks run over
+const benchProcesses = 1000
+
+// ensureProcesses starts sleeping children until at least n processes are
+// running, and stops them when the benchmark ends. It skips before starting
+// anything if the children can't be started here.
+func ensureProcesses(b *testing.B, n int) {
+	b.Helper()
+	pids, err := process.Pids()
+	if err != nil {
+		b.Fatal(err)
+	}
+	missing := n - len(pids)
+	if missing <= 0 {
+		return
+	}
+	if runtime.GOOS == "windows" {
+		b.Skipf("need %d more processes to reach %d", missing, n)
+	}
+	if _, err := exec.LookPath("sleep"); err != nil {
+		b.Skipf("need %d more processes to reach %d: %v", missing, n, err)
+	}
+
+	children := make([]*exec.Cmd, 0, missing)
+	b.Cleanup(func() {
+		for _, cmd := range children {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	})
+	for i := 0; i < missing; i++ {
+		cmd := exec.Command("sleep", "600")
+		if err := cmd.Start(); err != nil {
+			b.Fatalf("started %d of %d processes: %v", i, missing, err)
+		}
+		children = append(children, cmd)
+	}
+}
+
+// benchmarkCollect times collection passes with opts, after a first pass
+// that fills the cache the way a running sysgomon has it
+func benchmarkCollect(b *testing.B, opts CollectOptions) {
+	ensureProcesses(b, benchProcesses)
+	c := NewProcessCollector()
+	if _, _, err := c.collect(opts); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	reads := readSyscalls()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := c.collect(opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportReads(b, reads)
+}
+
+// BenchmarkCollectFull gathers every field for every process
+func BenchmarkCollectFull(b *testing.B) {
+	benchmarkCollect(b, CollectOptions{ShowIO: true, ShowNice: true, ShowOOM: true})
+}
+
+// BenchmarkCollectLimited gathers the expensive fields only for a screenful
+// of rows, as the UI asks for
+func BenchmarkCollectLimited(b *testing.B) {
+	keep := map[int32]bool{int32(os.Getpid()): true}
+	benchmarkCollect(b, CollectOptions{
+		ShowIO: true, ShowNice: true, ShowOOM: true,
+		Limit: 40, Keep: keep,
+	})
+}
+
+// BenchmarkCachedProcess times looking up every running process in a warm
+// cache, which happens once per process on every pass. The stats are read
+// beforehand, since a pass reads them anyway for CPU times and parents.
+func BenchmarkCachedProcess(b *testing.B) {
+	ensureProcesses(b, benchProcesses)
+	pids, err := process.Pids()
+	if err != nil {
+		b.Fatal(err)
+	}
+	c := NewProcessCollector()
+	stats := make([]processStat, len(pids))
+	for i, pid := range pids {
+		stats[i], _ = readProcessStat(pid)
+		c.cachedProcess(pid, &stats[i])
+	}
+	b.ReportAllocs()
+	reads := readSyscalls()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, pid := range pids {
+			c.cachedProcess(pid, &stats[j])
+		}
+	}
+	reportReads(b, reads)
+}
+
+// BenchmarkCommandLine times reading every running process's command line,
+// once with the cache cleared before each read and once from a warm cache
+func BenchmarkCommandLine(b *testing.B) {
+	ensureProcesses(b, benchProcesses)
+	// Let the children settle so their command lines can be cached
+	time.Sleep(cmdlineSettle)
+	pids, err := process.Pids()
+	if err != nil {
+		b.Fatal(err)
+	}
+	c := NewProcessCollector()
+	var entries []*processEntry
+	for _, pid := range pids {
+		stat, err := readProcessStat(pid)
+		if err != nil {
+			continue
+		}
+		if entry, err := c.cachedProcess(pid, &stat); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		reads := readSyscalls()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, entry := range entries {
+				entry.cmdlineRead = false
+				entry.commandLine("")
+			}
+		}
+		reportReads(b, reads)
+	})
+	b.Run("cached", func(b *testing.B) {
+		for _, entry := range entries {
+			entry.commandLine("")
+		}
+		b.ReportAllocs()
+		reads := readSyscalls()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, entry := range entries {
+				entry.commandLine("")
+			}
+		}
+		reportReads(b, reads)
+	})
+}
+
+// readSyscalls returns how many read system calls this process has made so
+// far, from /proc/self/io, or -1 where that isn't available
+func readSyscalls() int64 {
+	data, err := os.ReadFile("/proc/self/io")
+	if err != nil {
+		return -1
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "syscr: "); ok {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return -1
+			}
+			return n
+		}
+	}
+	return -1
+}
+
+// reportReads reports the read system calls made per op since before, as
+// returned by readSyscalls. It stops the timer so the count isn't timed.
+func reportReads(b *testing.B, before int64) {
+	b.StopTimer()
+	after := readSyscalls()
+	if before < 0 || after < 0 {
+		return
+	}
+	b.ReportMetric(float64(after-before)/float64(b.N), "reads/op")
+}
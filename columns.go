@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// processColumn describes one column the process table can show
+type processColumn struct {
+	Name     string  // Identifier used with --columns
+	Title    string  // Header text
+	Width    int     // Fixed width in cells, or 0 to share the leftover width
+	Weight   float64 // Share of the leftover width when Width is 0
+	Fill     bool    // Absorb whatever width the other columns leave over
+	Right    bool    // Right-align the header and values
+	Sortable bool    // Whether Sort applies to this column
+	Sort     SortKey // Sort key marked with an arrow in the header
+
+	// Value returns the cell text for p, given the column's width
+	Value func(p ProcessInfo, width int) string
+	// Color optionally returns a foreground color name for p's cell, or ""
+	Color func(p ProcessInfo) string
+}
+
+// processColumns lists every available column in its default order
+var processColumns = []*processColumn{
+	{Name: "pid", Title: "PID", Width: pidColumnWidth, Right: true, Sortable: true, Sort: SortByPID,
+		Value: func(p ProcessInfo, _ int) string { return fmt.Sprint(p.PID) }},
+	{Name: "user", Title: "User", Width: userColumnWidth,
+		Value: func(p ProcessInfo, _ int) string { return p.User }},
+	{Name: "state", Title: "S", Width: stateColumnWidth,
+		Value: func(p ProcessInfo, _ int) string { return stateMarker(p.Status) }},
+	{Name: "ni", Title: "NI", Width: niceColumnWidth, Right: true,
+		Value: func(p ProcessInfo, _ int) string { return formatNice(p.Nice) },
+		Color: func(p ProcessInfo) string {
+			if elevatedPriority(p.Nice) {
+				return "warn"
+			}
+			return ""
+		}},
+	{Name: "name", Title: "Name", Weight: 0.2, Sortable: true, Sort: SortByName,
+		Value: func(p ProcessInfo, _ int) string { return displayName(p) }},
+	{Name: "cpu", Title: "CPU%", Weight: 0.1, Sortable: true, Sort: SortByCPU,
+		Value: func(p ProcessInfo, _ int) string { return fmt.Sprintf("%.1f", p.CPU) }},
+	{Name: "mem", Title: "Mem%", Weight: 0.1, Sortable: true, Sort: SortByMemory,
+		Value: func(p ProcessInfo, _ int) string { return fmt.Sprintf("%.1f", p.Memory) }},
+	{Name: "rss", Title: "RSS", Width: rssColumnWidth, Right: true, Sortable: true, Sort: SortByMemory,
+		Value: func(p ProcessInfo, _ int) string { return formatBytes(p.RSS) }},
+	{Name: "shared", Title: "Shr", Width: rssColumnWidth, Right: true,
+		Value: func(p ProcessInfo, _ int) string { return formatSize(p.Shared) }},
+	{Name: "swap", Title: "Swap", Width: rssColumnWidth, Right: true,
+		Value: func(p ProcessInfo, _ int) string { return formatSize(p.Swap) }},
+	{Name: "threads", Title: "Thr", Width: threadsColumnWidth, Right: true, Sortable: true, Sort: SortByThreads,
+		Value: func(p ProcessInfo, _ int) string { return formatCount(p.Threads) }},
+	{Name: "time", Title: "Time+", Width: timeColumnWidth, Right: true, Sortable: true, Sort: SortByTime,
+		Value: func(p ProcessInfo, _ int) string { return formatCPUTime(p.CPUTime) }},
+	{Name: "age", Title: "Age", Width: ageColumnWidth, Right: true, Sortable: true, Sort: SortByAge,
+		Value: func(p ProcessInfo, _ int) string { return formatAge(p.Created) }},
+	{Name: "ctxsw", Title: "Ctxsw/s", Width: ctxColumnWidth, Right: true,
+		Value: func(p ProcessInfo, _ int) string { return formatSwitchRate(p.CtxSwitchRate) }},
+	{Name: "container", Title: "Container", Width: containerColumnWidth,
+		Value: func(p ProcessInfo, _ int) string { return valueOrDash(p.Container, nil) }},
+	{Name: "oom", Title: "OOM", Width: oomColumnWidth, Right: true, Sortable: true, Sort: SortByOOM,
+		Value: func(p ProcessInfo, _ int) string { return formatCount(p.OOMScore) },
+		Color: func(p ProcessInfo) string {
+			if p.OOMScore > oomScoreWarning {
+				return "crit"
+			}
+			return ""
+		}},
+	{Name: "fds", Title: "FDs", Width: fdsColumnWidth, Right: true, Sortable: true, Sort: SortByFDs,
+		Value: func(p ProcessInfo, _ int) string { return formatCount(p.FDs) },
+		Color: func(p ProcessInfo) string {
+			if p.FDsNear {
+				return "crit"
+			}
+			return ""
+		}},
+	{Name: "read", Title: "Read/s", Width: ioColumnWidth, Right: true, Sortable: true, Sort: SortByRead,
+		Value: func(p ProcessInfo, _ int) string { return formatRate(p.ReadBPS) }},
+	{Name: "write", Title: "Write/s", Width: ioColumnWidth, Right: true, Sortable: true, Sort: SortByWrite,
+		Value: func(p ProcessInfo, _ int) string { return formatRate(p.WriteBPS) }},
+	{Name: "command", Title: "Command", Weight: 0.6, Fill: true,
+		Value: func(p ProcessInfo, width int) string { return formatCommand(p.Command, width) }},
+}
+
+// userViewColumns are the columns of the per-user view
+var userViewColumns = []*processColumn{
+	{Name: "user", Title: "User", Width: userViewNameWidth, Sortable: true, Sort: SortByName,
+		Value: func(p ProcessInfo, _ int) string { return p.User }},
+	{Name: "procs", Title: "Procs", Width: userViewCountWidth, Right: true,
+		Value: func(p ProcessInfo, _ int) string { return fmt.Sprint(p.Count) }},
+	{Name: "cpu", Title: "CPU%", Width: userViewCPUWidth, Right: true, Sortable: true, Sort: SortByCPU,
+		Value: func(p ProcessInfo, _ int) string { return fmt.Sprintf("%.1f", p.CPU) }},
+	{Name: "rss", Title: "RSS", Width: rssColumnWidth, Right: true, Sortable: true, Sort: SortByMemory,
+		Value: func(p ProcessInfo, _ int) string { return formatBytes(p.RSS) }},
+}
+
+// Column widths of the per-user view
+const (
+	userViewNameWidth  = 16
+	userViewCountWidth = 6
+	userViewCPUWidth   = 7
+)
+
+// defaultColumns is the column set used when --columns is not given
+const defaultColumns = "pid,user,state,ni,name,cpu,mem,threads,time,age,command"
+
+// lookupColumn returns the column called name, or nil if there is none
+func lookupColumn(name string) *processColumn {
+	for _, col := range processColumns {
+		if col.Name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+// columnNames returns the names of every available column
+func columnNames() []string {
+	names := make([]string, len(processColumns))
+	for i, col := range processColumns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// parseColumns turns a comma-separated list of column names, such as
+// "pid,user,cpu,mem,command", into the columns to show in that order
+func parseColumns(spec string) ([]*processColumn, error) {
+	var columns []*processColumn
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		col := lookupColumn(name)
+		if col == nil {
+			return nil, fmt.Errorf("unknown column %q (valid columns: %s)", name, strings.Join(columnNames(), ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("column %q is listed more than once", name)
+		}
+		seen[name] = true
+		columns = append(columns, col)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns given (valid columns: %s)", strings.Join(columnNames(), ", "))
+	}
+	return columns, nil
+}
+
+// ColumnMenu is an overlay listing every column with a checkbox, used to
+// show and hide process table columns at runtime
+type ColumnMenu struct {
+	*widgets.List
+	Active bool // Whether the menu is open and capturing keys
+}
+
+func createColumnMenu() *ColumnMenu {
+	cm := &ColumnMenu{
+		List: widgets.NewList(),
+	}
+	cm.Title = "Columns (Space toggles, Esc closes)"
+	cm.Border = true
+	cm.TitleStyle.Fg = theme.Title
+	cm.TextStyle = ui.NewStyle(theme.Text)
+	cm.SelectedRowStyle = ui.NewStyle(theme.Text, ui.ColorClear, ui.ModifierReverse)
+	return cm
+}
+
+// Open shows the menu with the columns of pl ticked, centered within area
+func (cm *ColumnMenu) Open(pl *ProcessList, area image.Rectangle) {
+	cm.Active = true
+	cm.SelectedRow = 0
+	cm.update(pl)
+	cm.Center(area)
+}
+
+// Center positions the menu in the middle of area
+func (cm *ColumnMenu) Center(area image.Rectangle) {
+	width := len(cm.Title) + 4
+	height := len(processColumns) + 2
+	if width > area.Dx() {
+		width = area.Dx()
+	}
+	if height > area.Dy() {
+		height = area.Dy()
+	}
+	x := area.Min.X + (area.Dx()-width)/2
+	y := area.Min.Y + (area.Dy()-height)/2
+	cm.SetRect(x, y, x+width, y+height)
+}
+
+// update rebuilds the checkbox rows from the columns pl currently shows
+func (cm *ColumnMenu) update(pl *ProcessList) {
+	cm.Rows = make([]string, len(processColumns))
+	for i, col := range processColumns {
+		mark := " "
+		if pl.HasColumn(col.Name) {
+			mark = "x"
+		}
+		cm.Rows[i] = fmt.Sprintf("[%s] %-8s %s", mark, col.Name, col.Title)
+	}
+}
+
+// SelectedColumn returns the name of the highlighted column
+func (cm *ColumnMenu) SelectedColumn() string {
+	return processColumns[cm.SelectedRow].Name
+}
+
+// Close hides the menu
+func (cm *ColumnMenu) Close() {
+	cm.Active = false
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// connectionInterval is how often sockets are listed. Finding them means
+// walking every process's file descriptors, which is slow with many sockets.
+const connectionInterval = 5 * time.Second
+
+// tcpSummary counts TCP connections by state
+type tcpSummary struct {
+	States  map[string]int // Connections per state, e.g. "ESTABLISHED"
+	Limited bool           // Only the current user's sockets could be listed
+	Err     error
+}
+
+// tcpSummaryStates are the states shown, in order, with their labels. SYN_SENT
+// and SYN_RECV are combined, and the closing states not listed are left out.
+var tcpSummaryStates = []struct {
+	Label  string
+	States []string
+}{
+	{"estab", []string{"ESTABLISHED"}},
+	{"listen", []string{"LISTEN"}},
+	{"syn", []string{"SYN_SENT", "SYN_RECV"}},
+	{"time_wait", []string{"TIME_WAIT"}},
+	{"close_wait", []string{"CLOSE_WAIT"}},
+}
+
+// Text renders the counts, e.g. "TCP: estab 42  listen 8  syn 0
+// time_wait 311  close_wait 2"
+func (s *tcpSummary) Text() string {
+	if s.Err != nil {
+		return "[TCP:](fg:label) n/a"
+	}
+	var parts []string
+	for _, st := range tcpSummaryStates {
+		count := 0
+		for _, state := range st.States {
+			count += s.States[state]
+		}
+		parts = append(parts, fmt.Sprintf("%s %d", st.Label, count))
+	}
+	text := "[TCP:](fg:label) " + strings.Join(parts, "  ")
+	if s.Limited {
+		text += "  [(own processes only)](fg:warn)"
+	}
+	return text
+}
+
+// socketsLimited reports whether listing sockets only sees the current
+// user's. On Linux every socket is read from /proc/net, so the counts are
+// complete without root; elsewhere they come from lsof or similar, which only
+// sees other users' processes when run as root.
+func socketsLimited() bool {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return false
+	}
+	return os.Geteuid() != 0
+}
+
+// ConnectionCollector lists sockets on its own goroutine every
+// connectionInterval, publishing the results on Summaries
+type ConnectionCollector struct {
+	Summaries chan tcpSummary // Latest connection counts
+	done      chan struct{}   // Closed by Stop to end the goroutine
+	wg        sync.WaitGroup  // Tracks the running goroutine
+}
+
+func NewConnectionCollector() *ConnectionCollector {
+	return &ConnectionCollector{
+		Summaries: make(chan tcpSummary, 1),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start launches the collector goroutine
+func (c *ConnectionCollector) Start() {
+	c.wg.Add(1)
+	go c.run()
+}
+
+// Stop ends the collector goroutine and waits for it to exit
+func (c *ConnectionCollector) Stop() {
+	close(c.done)
+	c.wg.Wait()
+}
+
+func (c *ConnectionCollector) run() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(connectionInterval)
+	defer ticker.Stop()
+	for {
+		summary := collectTCPSummary()
+
+		// Replace any summary the UI has not picked up yet
+		select {
+		case <-c.Summaries:
+		default:
+		}
+		select {
+		case c.Summaries <- summary:
+		case <-c.done:
+			return
+		}
+
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectTCPSummary lists TCP sockets and counts them by state
+func collectTCPSummary() tcpSummary {
+	conns, err := net.Connections("tcp")
+	if err != nil {
+		return tcpSummary{Err: err}
+	}
+	summary := tcpSummary{States: make(map[string]int), Limited: socketsLimited()}
+	for _, conn := range conns {
+		summary.States[conn.Status]++
+	}
+	return summary
+}
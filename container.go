@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// containerIDPattern matches the 64 hex digit container IDs that Docker,
+// containerd, CRI-O, and Podman embed in cgroup paths, whether as a path
+// element (/docker/<id>) or in a systemd scope (docker-<id>.scope)
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// containerIDLength is how many characters of a container ID are shown,
+// matching the short IDs printed by docker ps
+const containerIDLength = 12
+
+// processContainer returns the short ID of the container pid runs in, or ""
+// when it isn't containerized or its cgroups can't be read. Both the cgroup
+// v1 layout (one line per controller) and the v2 unified line are handled.
+func processContainer(pid int32) string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+	f, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Each line is hierarchy-ID:controllers:path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if id := containerIDPattern.FindString(fields[2]); id != "" {
+			return id[:containerIDLength]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/load"
+)
+
+// Load average history settings. The kernel only recalculates the load
+// average every five seconds, so sampling faster would just repeat values.
+const (
+	loadSampleInterval = 5 * time.Second
+	loadHistoryLength  = 24 // Two minutes of 1-minute load averages
+)
+
+// coreHistoryLength is how many utilization samples each core keeps for its
+// sparkline, enough to fill a cell on a wide terminal
+const coreHistoryLength = 200
+
+// cpuHistory is a fixed-size ring buffer of utilization samples
+type cpuHistory struct {
+	samples []float64
+	next    int  // Slot the next sample is written to
+	full    bool // Whether every slot has been written
+}
+
+func newCPUHistory(size int) *cpuHistory {
+	return &cpuHistory{samples: make([]float64, size)}
+}
+
+// Add records a sample, overwriting the oldest once the buffer is full
+func (h *cpuHistory) Add(value float64) {
+	h.samples[h.next] = value
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Recent returns up to n of the newest samples, oldest first
+func (h *cpuHistory) Recent(n int) []float64 {
+	count := h.next
+	if h.full {
+		count = len(h.samples)
+	}
+	if n > count {
+		n = count
+	}
+	if n <= 0 {
+		return nil
+	}
+	recent := make([]float64, n)
+	for i := range recent {
+		recent[i] = h.samples[(h.next-n+i+len(h.samples))%len(h.samples)]
+	}
+	return recent
+}
+
+// cpuBreakdown splits overall CPU time into where it went between two
+// cpu.Times samples, exposing the iowait and steal time that a single
+// utilization figure hides
+type cpuBreakdown struct {
+	User   float64 // Percentage in user space, including niced processes
+	System float64 // Percentage in the kernel, including interrupts
+	IOWait float64 // Percentage idle while waiting on I/O
+	Steal  float64 // Percentage taken by the hypervisor for other guests
+	Idle   float64 // Percentage idle
+	Valid  bool    // Whether two comparable samples have been seen
+
+	prev *cpu.TimesStat
+}
+
+// Update takes a new cpu.Times sample and recomputes the percentages from
+// the difference with the previous one. The first sample only sets the
+// baseline, and so does a sample whose counters went backwards, as they can
+// after a suspend or a CPU going offline.
+func (b *cpuBreakdown) Update() {
+	times, err := cpu.Times(false)
+	if err != nil || len(times) == 0 {
+		b.Valid = false
+		return
+	}
+	cur := times[0]
+	prev := b.prev
+	b.prev = &cur
+	if prev == nil {
+		return
+	}
+
+	user := (cur.User + cur.Nice) - (prev.User + prev.Nice)
+	system := (cur.System + cur.Irq + cur.Softirq) - (prev.System + prev.Irq + prev.Softirq)
+	iowait := cur.Iowait - prev.Iowait
+	steal := cur.Steal - prev.Steal
+	idle := cur.Idle - prev.Idle
+	total := user + system + iowait + steal + idle
+	if user < 0 || system < 0 || iowait < 0 || steal < 0 || idle < 0 || total <= 0 {
+		b.Valid = false
+		return
+	}
+	b.User = user / total * 100
+	b.System = system / total * 100
+	b.IOWait = iowait / total * 100
+	b.Steal = steal / total * 100
+	b.Idle = idle / total * 100
+	b.Valid = true
+}
+
+// String renders the breakdown as e.g. "usr 32% sys 11% io 4% steal 0%",
+// or "" until two samples have been compared
+func (b *cpuBreakdown) String() string {
+	if !b.Valid {
+		return ""
+	}
+	return fmt.Sprintf("usr %.0f%% sys %.0f%% io %.0f%% steal %.0f%%", b.User, b.System, b.IOWait, b.Steal)
+}
+
+// kernelRates tracks the system-wide context switch and interrupt rates
+// from the ctxt and intr counters in /proc/stat, which show scheduler thrash
+// that per-core utilization hides. They are only available on Linux.
+type kernelRates struct {
+	CtxSwitches float64 // Context switches per second
+	Interrupts  float64 // Interrupts per second
+	Valid       bool    // Whether two comparable samples have been seen
+
+	ctxt, intr uint64    // Counters at the last sample
+	last       time.Time // When the last sample was taken
+}
+
+// Update takes a new sample and recomputes the rates. The first sample
+// only sets the baseline, as does one taken after the machine was suspended
+// or after the counters went backwards.
+func (k *kernelRates) Update() {
+	ctxt, intr, ok := readKernelCounters()
+	if !ok {
+		k.Valid = false
+		return
+	}
+	now := time.Now()
+	prev, prevCtxt, prevIntr := k.last, k.ctxt, k.intr
+	k.ctxt, k.intr, k.last = ctxt, intr, now
+	elapsed, ok := sampleElapsed(prev, now)
+	if !ok {
+		k.Valid = false
+		return
+	}
+	var okCtxt, okIntr bool
+	k.CtxSwitches, okCtxt = counterRate(prevCtxt, ctxt, elapsed)
+	k.Interrupts, okIntr = counterRate(prevIntr, intr, elapsed)
+	k.Valid = okCtxt && okIntr
+}
+
+// readKernelCounters reads the total context switches and interrupts since
+// boot from /proc/stat
+func readKernelCounters() (ctxt, intr uint64, ok bool) {
+	if runtime.GOOS != "linux" {
+		return 0, 0, false
+	}
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, 0, false
+	}
+	defer f.Close()
+
+	var haveCtxt, haveIntr bool
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // The intr line lists every IRQ
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "ctxt":
+			ctxt, err = strconv.ParseUint(fields[1], 10, 64)
+			haveCtxt = err == nil
+		case "intr":
+			// The first value is the total, followed by per-IRQ counts
+			intr, err = strconv.ParseUint(fields[1], 10, 64)
+			haveIntr = err == nil
+		}
+	}
+	return ctxt, intr, haveCtxt && haveIntr
+}
+
+// sharedState carries readings from one section of the display to another,
+// such as the process snapshot's biggest CPU consumer to the CPU gauges
+type sharedState struct {
+	TopProcess *ProcessInfo // Largest CPU consumer in the latest snapshot, nil when none is busy
+}
+
+// topProcessMinimum is the CPU% below which the largest consumer isn't
+// worth naming
+const topProcessMinimum = 5.0
+
+// SetProcesses records the largest CPU consumer among processes
+func (s *sharedState) SetProcesses(processes []ProcessInfo) {
+	s.TopProcess = nil
+	for _, p := range processes {
+		if p.CPU >= topProcessMinimum && (s.TopProcess == nil || p.CPU > s.TopProcess.CPU) {
+			top := p // A copy, since the process list sorts the snapshot in place
+			s.TopProcess = &top
+		}
+	}
+}
+
+// averageTitle returns the average CPU gauge's title, naming the largest CPU
+// consumer when there is one, e.g. "Avg CPU (ffmpeg 610%)"
+func (s *sharedState) averageTitle(label string) string {
+	if s.TopProcess == nil {
+		return label
+	}
+	return fmt.Sprintf("%s (%s %.0f%%)", label, s.TopProcess.Name, s.TopProcess.CPU)
+}
+
+// CPUSummary is the line above the CPU gauges with the core count and
+// system-wide CPU readings such as the load average
+type CPUSummary struct {
+	*widgets.Paragraph
+	Cores    int
+	TempWarn float64 // Temperature in °C above which the readout turns red
+
+	load        *load.AvgStat       // Latest load averages, nil when unavailable
+	loadHistory []float64           // Recent 1-minute load averages, oldest first
+	lastSample  time.Time           // When loadHistory was last appended to
+	temperature *temperatureReading // Hottest CPU sensor, nil until read
+	kernel      kernelRates         // System-wide context switch and interrupt rates
+}
+
+func createCPUSummary(cores int) *CPUSummary {
+	s := &CPUSummary{
+		Paragraph: widgets.NewParagraph(),
+		Cores:     cores,
+		TempWarn:  defaultTempWarning,
+	}
+	s.Border = false
+	s.refresh()
+	return s
+}
+
+// Update reads the load averages and kernel counters and rebuilds the
+// summary text
+func (s *CPUSummary) Update() {
+	s.updateLoad()
+	s.kernel.Update()
+	s.refresh()
+}
+
+// SetTemperature records a CPU temperature reading and rebuilds the text
+func (s *CPUSummary) SetTemperature(reading temperatureReading) {
+	s.temperature = &reading
+	s.refresh()
+}
+
+// updateLoad reads the load averages, leaving them unset on Windows, which
+// has no load average of its own
+func (s *CPUSummary) updateLoad() {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	avg, err := load.Avg()
+	if err != nil {
+		s.load = nil
+		return
+	}
+	s.load = avg
+	if time.Since(s.lastSample) < loadSampleInterval {
+		return
+	}
+	s.lastSample = time.Now()
+	s.loadHistory = append(s.loadHistory, avg.Load1)
+	if len(s.loadHistory) > loadHistoryLength {
+		s.loadHistory = s.loadHistory[len(s.loadHistory)-loadHistoryLength:]
+	}
+}
+
+func (s *CPUSummary) refresh() {
+	parts := []string{
+		fmt.Sprintf("[CPU Utilization (%d cores)](fg:text,mod:bold)", s.Cores),
+		s.loadText(),
+	}
+	if text := s.temperatureText(); text != "" {
+		parts = append(parts, text)
+	}
+	if s.kernel.Valid {
+		parts = append(parts, fmt.Sprintf("[Ctxsw:](fg:label) %s [Intr:](fg:label) %s",
+			formatSwitchRate(s.kernel.CtxSwitches), formatSwitchRate(s.kernel.Interrupts)))
+	}
+	s.Text = strings.Join(parts, "  ")
+}
+
+// loadText renders the 1, 5 and 15-minute load averages followed by a
+// sparkline of the recent 1-minute values
+func (s *CPUSummary) loadText() string {
+	if s.load == nil {
+		return "[Load:](fg:label) n/a"
+	}
+	text := fmt.Sprintf("[Load:](fg:label) %s %s %s",
+		s.formatLoad(s.load.Load1), s.formatLoad(s.load.Load5), s.formatLoad(s.load.Load15))
+	if len(s.loadHistory) > 1 {
+		top := float64(s.Cores)
+		for _, v := range s.loadHistory {
+			if v > top {
+				top = v
+			}
+		}
+		text += " " + sparkline(s.loadHistory, top)
+	}
+	return text
+}
+
+// temperatureText renders the hottest CPU sensor, or "" on machines without
+// CPU sensors
+func (s *CPUSummary) temperatureText() string {
+	if s.temperature == nil || !s.temperature.OK {
+		return ""
+	}
+	color := "ok"
+	if s.temperature.Celsius > s.TempWarn {
+		color = "crit"
+	}
+	return fmt.Sprintf("[Temp:](fg:label) [%.0f°C](fg:%s)", s.temperature.Celsius, color)
+}
+
+// formatLoad colors a load average relative to the core count: green while
+// every process can have a core, yellow up to twice that, red beyond
+func (s *CPUSummary) formatLoad(value float64) string {
+	color := "ok"
+	switch cores := float64(s.Cores); {
+	case value > 2*cores:
+		color = "crit"
+	case value >= cores:
+		color = "warn"
+	}
+	return fmt.Sprintf("[%.2f](fg:%s)", value, color)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// ProcessDetail is a panel showing everything gopsutil exposes for a single
+// process. It is drawn over the disk section while open.
+type ProcessDetail struct {
+	*widgets.Paragraph
+	Active bool   // Whether the panel is open
+	PID    int32  // Process being inspected
+	Name   string // Process name, kept for the title once the process exits
+}
+
+func createProcessDetail() *ProcessDetail {
+	pd := &ProcessDetail{
+		Paragraph: widgets.NewParagraph(),
+	}
+	pd.Border = true
+	pd.TitleStyle.Fg = theme.Title
+	pd.BorderStyle.Fg = theme.Panel
+	return pd
+}
+
+// Open shows the panel for the given process and loads its details
+func (pd *ProcessDetail) Open(info ProcessInfo, cpuHistory []float64) {
+	pd.Active = true
+	pd.PID = info.PID
+	pd.Name = info.Name
+	pd.Title = fmt.Sprintf("Process %d: %s (Enter/Esc to close)", info.PID, info.Name)
+	pd.update(cpuHistory)
+}
+
+// Close hides the panel
+func (pd *ProcessDetail) Close() {
+	pd.Active = false
+}
+
+// update re-reads the process details and shows cpuHistory as a sparkline.
+// A process that has exited is reported as such rather than as an error.
+func (pd *ProcessDetail) update(cpuHistory []float64) {
+	p, err := process.NewProcess(pd.PID)
+	if err != nil {
+		pd.Text = "[process exited](fg:crit)"
+		return
+	}
+
+	lines := []string{
+		detailLine("Command", valueOrDash(p.Cmdline())),
+		detailLine("Exe", valueOrDash(p.Exe())),
+		detailLine("Cwd", valueOrDash(p.Cwd())),
+	}
+
+	status := "-"
+	if s, err := p.Status(); err == nil {
+		status = strings.Join(s, ",")
+	}
+	started := "-"
+	if ct, err := p.CreateTime(); err == nil {
+		started = time.UnixMilli(ct).Format("2006-01-02 15:04:05")
+	}
+	lines = append(lines, detailLine("Status", status)+"  "+detailLine("Started", started))
+
+	threads, fds := "-", "-"
+	if n, err := p.NumThreads(); err == nil {
+		threads = fmt.Sprintf("%d", n)
+	}
+	if n, err := p.NumFDs(); err == nil {
+		fds = fmt.Sprintf("%d", n)
+	}
+	lines = append(lines, detailLine("Threads", threads)+"  "+detailLine("Open FDs", fds))
+
+	memory := "-"
+	if m, err := p.MemoryInfo(); err == nil {
+		memory = fmt.Sprintf("RSS %s / VMS %s", formatBytes(m.RSS), formatBytes(m.VMS))
+	}
+	cpuTimes := "-"
+	if t, err := p.Times(); err == nil {
+		cpuTimes = fmt.Sprintf("user %.2fs / system %.2fs", t.User, t.System)
+	}
+	lines = append(lines, detailLine("Memory", memory)+"  "+detailLine("CPU time", cpuTimes))
+
+	if len(cpuHistory) > 0 {
+		peak := maxInSlice(cpuHistory)
+		lines = append(lines, detailLine("CPU history", fmt.Sprintf("%s (now %.1f%%, max %.1f%%)",
+			sparkline(cpuHistory, max(peak, 100)), cpuHistory[len(cpuHistory)-1], peak)))
+	}
+
+	pd.Text = strings.Join(lines, "\n")
+}
+
+// sparkBlocks are the glyphs used by sparkline, from lowest to highest
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders values as a one-line bar graph scaled to top
+func sparkline(values []float64, top float64) string {
+	var sb strings.Builder
+	for _, v := range values {
+		level := 0
+		if top > 0 {
+			level = int(v / top * float64(len(sparkBlocks)-1))
+		}
+		if level < 0 {
+			level = 0
+		} else if level >= len(sparkBlocks) {
+			level = len(sparkBlocks) - 1
+		}
+		sb.WriteRune(sparkBlocks[level])
+	}
+	return sb.String()
+}
+
+func detailLine(label, value string) string {
+	return fmt.Sprintf("[%s:](fg:label) %s", label, value)
+}
+
+// valueOrDash returns "-" for failed or empty lookups
+func valueOrDash(value string, err error) string {
+	if err != nil || value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// defaultExcludedDisks are left out of the disk section unless
+// --exclude-disks or --all-disks say otherwise: loop devices, which snap
+// packages create by the dozen, RAM disks, zram swap, and device-mapper
+// volumes, whose I/O also shows up on the disks beneath them
+var defaultExcludedDisks = []string{"loop*", "ram*", "zram*", "dm-*"}
+
+// diskFilter picks which of the devices the OS reports are counted, so the
+// same bytes aren't counted once for a partition and again for its disk
+type diskFilter struct {
+	Partitions bool     // Count partitions rather than the disks holding them
+	Exclude    []string // Globs of devices left out entirely
+}
+
+// Apply returns the counters of the devices f counts. Whole disks are
+// counted by default; with Partitions set, each disk's partitions are
+// counted instead, and disks without partitions still count on their own.
+func (f diskFilter) Apply(counters map[string]disk.IOCountersStat) map[string]disk.IOCountersStat {
+	if len(f.Exclude) > 0 {
+		included := make(map[string]disk.IOCountersStat, len(counters))
+		for name, stat := range counters {
+			if !f.excluded(name) {
+				included[name] = stat
+			}
+		}
+		counters = included
+	}
+
+	partitioned := make(map[string]bool)
+	for name := range counters {
+		if parent, ok := partitionParent(name, counters); ok {
+			partitioned[parent] = true
+		}
+	}
+	kept := make(map[string]disk.IOCountersStat, len(counters))
+	for name, stat := range counters {
+		_, isPartition := partitionParent(name, counters)
+		if f.Partitions {
+			if partitioned[name] {
+				continue
+			}
+		} else if isPartition {
+			continue
+		}
+		kept[name] = stat
+	}
+	return kept
+}
+
+// excluded reports whether name is one of the Exclude devices
+func (f diskFilter) excluded(name string) bool {
+	for _, p := range f.Exclude {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// partitionParent returns the disk holding the partition name when that
+// disk is also among devices, going by the naming schemes in use: a
+// partition number straight after the disk's name on Linux (sda1, xvda1),
+// after a "p" when the disk's name ends in a digit (nvme0n1p1, mmcblk0p1),
+// and after an "s" on macOS (disk0s1). Windows reports volumes such as
+// "C:", which have no parent.
+func partitionParent(name string, devices map[string]disk.IOCountersStat) (string, bool) {
+	base := strings.TrimRight(name, "0123456789")
+	if base == name || base == "" {
+		return "", false
+	}
+	if n := len(base); n >= 2 && (base[n-1] == 'p' || base[n-1] == 's') && isDigit(base[n-2]) {
+		base = base[:n-1]
+	} else if isDigit(base[len(base)-1]) {
+		return "", false
+	}
+	if _, ok := devices[base]; ok {
+		return base, true
+	}
+	return "", false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// diskSelection chooses which device feeds the disk graph: every device, or
+// a single one picked with --disk or at runtime
+type diskSelection struct {
+	Selected string   // Device feeding the graph, or "" for every device
+	names    []string // Devices seen in the last sample, sorted
+}
+
+// Includes reports whether name's traffic feeds the graph
+func (s *diskSelection) Includes(name string) bool {
+	return s.Selected == "" || name == s.Selected
+}
+
+// Cycle steps the selection from every device to each one in turn and back
+// again
+func (s *diskSelection) Cycle() {
+	next := 0
+	if s.Selected != "" {
+		next = sort.SearchStrings(s.names, s.Selected)
+		if next < len(s.names) && s.names[next] == s.Selected {
+			next++
+		}
+	}
+	if next < len(s.names) {
+		s.Selected = s.names[next]
+	} else {
+		s.Selected = ""
+	}
+}
+
+// Label names the selection for the graph title, e.g. "nvme0n1" or "all"
+func (s *diskSelection) Label() string {
+	if s.Selected == "" {
+		return "all"
+	}
+	return s.Selected
+}
+
+// maxDiskStatsLines is how many devices the disk stats paragraph grows to
+// show before the rest are summed up as a count
+const maxDiskStatsLines = 6
+
+// latencyThresholds are the request latencies, in milliseconds, at which a
+// device's await turns yellow and red
+type latencyThresholds struct {
+	Warn float64
+	Crit float64
+}
+
+// defaultAwaitThresholds apply unless --disk-await-warn or --disk-await-crit
+// say otherwise. An idle SSD answers in well under a millisecond and a
+// spinning disk in around ten.
+var defaultAwaitThresholds = latencyThresholds{Warn: 20, Crit: 100}
+
+// Validate reports a negative threshold or a warning level that isn't below
+// the critical one
+func (t latencyThresholds) Validate() error {
+	if t.Warn < 0 {
+		return fmt.Errorf("thresholds must not be negative")
+	}
+	if t.Warn >= t.Crit {
+		return fmt.Errorf("warning threshold %g must be below critical threshold %g", t.Warn, t.Crit)
+	}
+	return nil
+}
+
+// Color returns the theme role name for a latency in milliseconds
+func (t latencyThresholds) Color(ms float64) string {
+	switch {
+	case ms >= t.Crit:
+		return "crit"
+	case ms >= t.Warn:
+		return "warn"
+	}
+	return "ok"
+}
+
+// diskDeviceRates is one device's throughput in the last sample
+type diskDeviceRates struct {
+	Name      string
+	ReadMBps  float64
+	WriteMBps float64
+	ReadIOPS  float64 // Read operations completed per second
+	WriteIOPS float64 // Write operations completed per second
+	AwaitMs   float64 // Average time requests completed in the sample took, queueing included
+	HasAwait  bool    // Whether AwaitMs is known: requests completed and the platform reports their time
+	Busy      float64 // Percentage of the sample the device had requests in flight
+	HasBusy   bool    // Whether Busy is known: the platform reports the device's I/O time
+}
+
+// deviceBusy returns the percentage of elapsed that a device spent with
+// requests in flight. Devices that serve several requests at once, such as
+// NVMe drives, can account more I/O time than wall time, so it is capped
+// at 100. It is unknown on platforms that leave IoTime at zero and when
+// the counter went backwards.
+func deviceBusy(prev, cur disk.IOCountersStat, elapsed time.Duration) (float64, bool) {
+	if cur.IoTime == 0 || cur.IoTime < prev.IoTime || elapsed <= 0 {
+		return 0, false
+	}
+	busy := float64(cur.IoTime-prev.IoTime) / float64(elapsed.Milliseconds()) * 100
+	return min(busy, 100), true
+}
+
+// requestAwait returns the average latency in milliseconds of the requests
+// completed between two samples, like iostat's await. It is unknown when
+// none completed, when a counter went backwards, and on platforms that
+// leave the time counters at zero.
+func requestAwait(prev, cur disk.IOCountersStat) (float64, bool) {
+	if cur.ReadTime == 0 && cur.WriteTime == 0 {
+		return 0, false
+	}
+	if cur.ReadCount < prev.ReadCount || cur.WriteCount < prev.WriteCount ||
+		cur.ReadTime < prev.ReadTime || cur.WriteTime < prev.WriteTime {
+		return 0, false
+	}
+	ops := (cur.ReadCount - prev.ReadCount) + (cur.WriteCount - prev.WriteCount)
+	if ops == 0 {
+		return 0, false
+	}
+	ms := (cur.ReadTime - prev.ReadTime) + (cur.WriteTime - prev.WriteTime)
+	return float64(ms) / float64(ops), true
+}
+
+// diskTracker turns per-device byte counters into rates, each device
+// keeping its own baseline
+type diskTracker struct {
+	Devices    []diskDeviceRates // Rates of each device with a baseline in the last sample, by name
+	Thresholds latencyThresholds // Await at which a device's latency turns yellow and red
+	Filter     diskFilter        // Which devices are counted
+
+	prev    map[string]disk.IOCountersStat // Counters at the last sample, by device name
+	last    time.Time                      // When the last sample was taken
+	sampled int                            // Number of devices in the last sample
+}
+
+func newDiskTracker(filter diskFilter, thresholds latencyThresholds) *diskTracker {
+	return &diskTracker{
+		Thresholds: thresholds,
+		Filter:     filter,
+		prev:       make(map[string]disk.IOCountersStat),
+	}
+}
+
+// Update takes a new sample of per-device counters and returns the combined
+// rates of the devices sel includes, named after the selection. Devices
+// Filter leaves out are ignored. Devices
+// whose counters were reset show zero, and so do all of them after a
+// suspend; their baselines start over from this sample.
+func (t *diskTracker) Update(counters map[string]disk.IOCountersStat, sel *diskSelection) diskDeviceRates {
+	counters = t.Filter.Apply(counters)
+	now := time.Now()
+	elapsed, valid := sampleElapsed(t.last, now)
+	t.last = now
+	t.Devices = t.Devices[:0]
+	sel.names = sel.names[:0]
+	t.sampled = len(counters)
+	total := diskDeviceRates{Name: sel.Label()}
+
+	for name, stat := range counters {
+		sel.names = append(sel.names, name)
+		prev, ok := t.prev[name]
+		t.prev[name] = stat
+		if !ok {
+			continue
+		}
+		readRate, readOK := counterRate(prev.ReadBytes, stat.ReadBytes, elapsed)
+		writeRate, writeOK := counterRate(prev.WriteBytes, stat.WriteBytes, elapsed)
+		readOps, readOpsOK := counterRate(prev.ReadCount, stat.ReadCount, elapsed)
+		writeOps, writeOpsOK := counterRate(prev.WriteCount, stat.WriteCount, elapsed)
+		if !valid || !readOK || !writeOK || !readOpsOK || !writeOpsOK {
+			readRate, writeRate, readOps, writeOps = 0, 0, 0, 0
+		}
+		d := diskDeviceRates{
+			Name:      name,
+			ReadMBps:  readRate / 1024 / 1024,
+			WriteMBps: writeRate / 1024 / 1024,
+			ReadIOPS:  readOps,
+			WriteIOPS: writeOps,
+		}
+		if valid {
+			d.AwaitMs, d.HasAwait = requestAwait(prev, stat)
+			d.Busy, d.HasBusy = deviceBusy(prev, stat, elapsed)
+		}
+		t.Devices = append(t.Devices, d)
+		if sel.Includes(name) {
+			total.ReadMBps += d.ReadMBps
+			total.WriteMBps += d.WriteMBps
+			total.ReadIOPS += d.ReadIOPS
+			total.WriteIOPS += d.WriteIOPS
+		}
+	}
+	sort.Strings(sel.names)
+	sort.Slice(t.Devices, func(i, j int) bool { return t.Devices[i].Name < t.Devices[j].Name })
+	return total
+}
+
+// Lines returns how many lines Text takes once every device in the last
+// sample has a baseline, at least two so the paragraph keeps its usual
+// height with a single device
+func (t *diskTracker) Lines() int {
+	lines := min(t.sampled, maxDiskStatsLines)
+	if lines < 2 {
+		return 2
+	}
+	return lines
+}
+
+// Text renders a line of rates per device, up to maxDiskStatsLines; the
+// last line counts the devices left out past that
+func (t *diskTracker) Text() string {
+	shown := t.Devices
+	if len(shown) > maxDiskStatsLines {
+		shown = shown[:maxDiskStatsLines-1]
+	}
+	lines := make([]string, 0, len(shown)+1)
+	for _, d := range shown {
+		lines = append(lines, fmt.Sprintf(
+			"[%-8s](fg:accent) R: [%8.2f MB/s](fg:read) (%s)  W: [%8.2f MB/s](fg:write) (%s)%s  Await: %s",
+			d.Name, d.ReadMBps, formatIOPS(d.ReadIOPS), d.WriteMBps, formatIOPS(d.WriteIOPS), busyText(d), t.awaitText(d),
+		))
+	}
+	if hidden := len(t.Devices) - len(shown); hidden > 0 {
+		lines = append(lines, fmt.Sprintf("[… and %d more devices](fg:dim)", hidden))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Busiest returns the device busiest in the last sample, or false when no
+// device's busy percentage is known
+func (t *diskTracker) Busiest() (diskDeviceRates, bool) {
+	var busiest diskDeviceRates
+	found := false
+	for _, d := range t.Devices {
+		if d.HasBusy && (!found || d.Busy > busiest.Busy) {
+			busiest, found = d, true
+		}
+	}
+	return busiest, found
+}
+
+// busyText renders a device's busy percentage, or nothing on platforms
+// that don't report it
+func busyText(d diskDeviceRates) string {
+	if !d.HasBusy {
+		return ""
+	}
+	return fmt.Sprintf("  Busy: %3.0f%%", d.Busy)
+}
+
+// awaitText renders a device's await colored by Thresholds, or "-" when it
+// isn't known
+func (t *diskTracker) awaitText(d diskDeviceRates) string {
+	if !d.HasAwait {
+		return "-"
+	}
+	return fmt.Sprintf("[%s](fg:%s)", formatLatency(d.AwaitMs), t.Thresholds.Color(d.AwaitMs))
+}
+
+// formatLatency formats a latency in milliseconds, with a decimal below 100
+func formatLatency(ms float64) string {
+	if ms < 100 {
+		return fmt.Sprintf("%.1f ms", ms)
+	}
+	return fmt.Sprintf("%.0f ms", ms)
+}
+
+// formatIOPS formats an operation rate compactly, e.g. "950 IOPS" or
+// "8.1k IOPS"
+func formatIOPS(rate float64) string {
+	if rate >= 1000 {
+		return fmt.Sprintf("%.1fk IOPS", rate/1000)
+	}
+	return fmt.Sprintf("%.0f IOPS", rate)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// diskDevices builds a counters map holding names
+func diskDevices(names ...string) map[string]disk.IOCountersStat {
+	devices := make(map[string]disk.IOCountersStat, len(names))
+	for _, name := range names {
+		devices[name] = disk.IOCountersStat{Name: name}
+	}
+	return devices
+}
+
+func TestPartitionParent(t *testing.T) {
+	devices := diskDevices(
+		"sda", "sda1", "sda2",
+		"nvme0n1", "nvme0n1p1",
+		"mmcblk0", "mmcblk0p1",
+		"xvdp", "xvdp1",
+		"disk0", "disk0s1", "disk0s2",
+		"C:", "md0", "sr0",
+	)
+	tests := []struct {
+		name   string
+		parent string
+		ok     bool
+	}{
+		{"sda", "", false},
+		{"sda1", "sda", true},
+		{"sda2", "sda", true},
+		// A trailing digit is part of the disk's name, not a partition
+		{"nvme0n1", "", false},
+		{"nvme0n1p1", "nvme0n1", true},
+		{"mmcblk0", "", false},
+		{"mmcblk0p1", "mmcblk0", true},
+		// A "p" after a letter is part of the disk's name
+		{"xvdp", "", false},
+		{"xvdp1", "xvdp", true},
+		{"disk0", "", false},
+		{"disk0s1", "disk0", true},
+		{"disk0s2", "disk0", true},
+		{"C:", "", false},
+		{"md0", "", false},
+		{"sr0", "", false},
+		// The parent must have been reported too
+		{"sdb1", "", false},
+		{"nvme1n1p2", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent, ok := partitionParent(tt.name, devices)
+			if parent != tt.parent || ok != tt.ok {
+				t.Errorf("partitionParent(%q) = %q, %v, want %q, %v", tt.name, parent, ok, tt.parent, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDiskFilterApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		devices []string
+		filter  diskFilter
+		want    []string
+	}{
+		{
+			name:    "linux disks",
+			devices: []string{"sda", "sda1", "sda2", "nvme0n1", "nvme0n1p1", "mmcblk0", "mmcblk0p1", "xvdp", "xvdp1", "sdb"},
+			want:    []string{"mmcblk0", "nvme0n1", "sda", "sdb", "xvdp"},
+		},
+		{
+			// sdb has no partitions, so it still counts
+			name:    "linux partitions",
+			devices: []string{"sda", "sda1", "sda2", "nvme0n1", "nvme0n1p1", "mmcblk0", "mmcblk0p1", "xvdp", "xvdp1", "sdb"},
+			filter:  diskFilter{Partitions: true},
+			want:    []string{"mmcblk0p1", "nvme0n1p1", "sda1", "sda2", "sdb", "xvdp1"},
+		},
+		{
+			name:    "linux excluded",
+			devices: []string{"sda", "sda1", "loop0", "loop1", "dm-0", "zram0"},
+			filter:  diskFilter{Exclude: defaultExcludedDisks},
+			want:    []string{"sda"},
+		},
+		{
+			name:    "macos disks",
+			devices: []string{"disk0", "disk0s1", "disk0s2", "disk1"},
+			want:    []string{"disk0", "disk1"},
+		},
+		{
+			name:    "macos partitions",
+			devices: []string{"disk0", "disk0s1", "disk0s2", "disk1"},
+			filter:  diskFilter{Partitions: true},
+			want:    []string{"disk0s1", "disk0s2", "disk1"},
+		},
+		{
+			name:    "windows volumes",
+			devices: []string{"C:", "D:"},
+			want:    []string{"C:", "D:"},
+		},
+		{
+			name:    "windows volumes as partitions",
+			devices: []string{"C:", "D:"},
+			filter:  diskFilter{Partitions: true},
+			want:    []string{"C:", "D:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept := tt.filter.Apply(diskDevices(tt.devices...))
+			var got []string
+			for name := range kept {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Apply() kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
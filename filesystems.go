@@ -0,0 +1,382 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// filesystemInterval is how often filesystem usage is read. Usage is read
+// on the collector's goroutine, since a hung network mount can block it
+// indefinitely.
+const filesystemInterval = 10 * time.Second
+
+// How a mount's time until full is projected: from a linear fit of its used
+// bytes over the last fillTrendSamples samples, damped by fillSmoothing so
+// the estimate doesn't jump with every sample. It is only shown for mounts
+// above fillWarnPercent that would fill within fillMaxHorizon; slower
+// growth is as good as stable.
+const (
+	fillTrendSamples = 30 // About five minutes at filesystemInterval
+	fillMinSamples   = 3
+	fillSmoothing    = 0.3
+	fillWarnPercent  = 80
+	fillMaxHorizon   = 7 * 24 * time.Hour
+)
+
+// filesystemFullPercent is the byte or inode usage above which a mount is
+// highlighted. Running out of inodes fails writes just like running out of
+// space, even with plenty of bytes free.
+const filesystemFullPercent = 90
+
+// filesystemMountWidth is the width of the panel's mount point column
+const filesystemMountWidth = 24
+
+// readOnlyImageTypes are filesystems that are always full by design, such
+// as the squashfs images snap packages mount, and are left out
+var readOnlyImageTypes = map[string]bool{"squashfs": true, "iso9660": true}
+
+// filesystem is the space and inode usage of a mounted filesystem
+type filesystem struct {
+	Mountpoint        string
+	Fstype            string
+	Total             uint64
+	Used              uint64
+	UsedPercent       float64
+	InodesTotal       uint64 // Zero when the filesystem doesn't report inodes, as with vfat
+	InodesUsed        uint64
+	InodesUsedPercent float64
+}
+
+// Full reports whether the filesystem's bytes or inodes are nearly used up
+func (f filesystem) Full() bool {
+	return f.UsedPercent >= filesystemFullPercent ||
+		f.InodesTotal > 0 && f.InodesUsedPercent >= filesystemFullPercent
+}
+
+// filesystemSnapshot is the result of one pass of the filesystem collector
+type filesystemSnapshot struct {
+	Filesystems []filesystem // Ordered by mount point
+	Err         error
+}
+
+// FilesystemCollector reads the usage of every mounted filesystem on its
+// own goroutine every filesystemInterval, publishing the results on
+// Snapshots
+type FilesystemCollector struct {
+	Snapshots chan filesystemSnapshot // Latest usage
+	done      chan struct{}           // Closed by Stop to end the goroutine
+}
+
+func NewFilesystemCollector() *FilesystemCollector {
+	return &FilesystemCollector{
+		Snapshots: make(chan filesystemSnapshot, 1),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start launches the collector goroutine
+func (c *FilesystemCollector) Start() {
+	go c.run()
+}
+
+// Stop ends the collector goroutine. Unlike the other collectors it doesn't
+// wait for it to exit, since a hung mount may never let it.
+func (c *FilesystemCollector) Stop() {
+	close(c.done)
+}
+
+func (c *FilesystemCollector) run() {
+	ticker := time.NewTicker(filesystemInterval)
+	defer ticker.Stop()
+	for {
+		snap := collectFilesystems()
+
+		// Replace any snapshot the UI has not picked up yet
+		select {
+		case <-c.Snapshots:
+		default:
+		}
+		select {
+		case c.Snapshots <- snap:
+		case <-c.done:
+			return
+		}
+
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectFilesystems reads the usage of each mount point once, leaving out
+// pseudo filesystems such as proc and sysfs, which report no size, and
+// read-only images
+func collectFilesystems() filesystemSnapshot {
+	partitions, err := disk.Partitions(true)
+	if err != nil {
+		return filesystemSnapshot{Err: err}
+	}
+
+	var snap filesystemSnapshot
+	seen := make(map[string]bool)
+	for _, part := range partitions {
+		if seen[part.Mountpoint] || readOnlyImageTypes[part.Fstype] {
+			continue
+		}
+		seen[part.Mountpoint] = true
+		usage, err := disk.Usage(part.Mountpoint)
+		if err != nil || usage.Total == 0 {
+			continue
+		}
+		snap.Filesystems = append(snap.Filesystems, filesystem{
+			Mountpoint:        part.Mountpoint,
+			Fstype:            part.Fstype,
+			Total:             usage.Total,
+			Used:              usage.Used,
+			UsedPercent:       usage.UsedPercent,
+			InodesTotal:       usage.InodesTotal,
+			InodesUsed:        usage.InodesUsed,
+			InodesUsedPercent: usage.InodesUsedPercent,
+		})
+	}
+	sort.Slice(snap.Filesystems, func(i, j int) bool {
+		return snap.Filesystems[i].Mountpoint < snap.Filesystems[j].Mountpoint
+	})
+	return snap
+}
+
+// usageSample is a mount's used bytes at one point in time
+type usageSample struct {
+	At   time.Time
+	Used float64
+}
+
+// fillTrend is how fast a mount's used bytes are growing
+type fillTrend struct {
+	samples []usageSample // The last fillTrendSamples samples, oldest first
+	rate    float64       // Damped growth in bytes per second
+}
+
+// add records a sample and updates the damped growth rate
+func (t *fillTrend) add(s usageSample) {
+	if len(t.samples) == fillTrendSamples {
+		t.samples = t.samples[1:]
+	}
+	t.samples = append(t.samples, s)
+	if len(t.samples) < fillMinSamples {
+		return
+	}
+	slope := usageSlope(t.samples)
+	if len(t.samples) == fillMinSamples {
+		t.rate = slope
+	} else {
+		t.rate += fillSmoothing * (slope - t.rate)
+	}
+}
+
+// usageSlope returns the least-squares slope of used bytes over time, in
+// bytes per second
+func usageSlope(samples []usageSample) float64 {
+	n := float64(len(samples))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x := s.At.Sub(samples[0].At).Seconds()
+		sumX += x
+		sumY += s.Used
+		sumXY += x * s.Used
+		sumXX += x * x
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
+
+// fillTrends tracks each mount's growth over the session, by mount point
+type fillTrends map[string]*fillTrend
+
+// Add records the usage in snap, forgetting mounts that are gone
+func (t fillTrends) Add(snap filesystemSnapshot, now time.Time) {
+	mounted := make(map[string]bool, len(snap.Filesystems))
+	for _, f := range snap.Filesystems {
+		mounted[f.Mountpoint] = true
+		trend, ok := t[f.Mountpoint]
+		if !ok {
+			trend = &fillTrend{}
+			t[f.Mountpoint] = trend
+		}
+		trend.add(usageSample{At: now, Used: float64(f.Used)})
+	}
+	for mount := range t {
+		if !mounted[mount] {
+			delete(t, mount)
+		}
+	}
+}
+
+// TimeToFull projects when f fills up at its damped growth rate, or false
+// when it is below fillWarnPercent, isn't growing, or wouldn't fill within
+// fillMaxHorizon
+func (t fillTrends) TimeToFull(f filesystem) (time.Duration, bool) {
+	trend, ok := t[f.Mountpoint]
+	if !ok || f.UsedPercent < fillWarnPercent || trend.rate <= 0 || f.Used >= f.Total {
+		return 0, false
+	}
+	seconds := float64(f.Total-f.Used) / trend.rate
+	if seconds > fillMaxHorizon.Seconds() {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// formatTimeToFull formats a projection roughly, e.g. "~40m", "~3h", or
+// "~2d"
+func formatTimeToFull(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "~1m"
+	case d < time.Hour:
+		return fmt.Sprintf("~%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("~%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("~%dd", int(d.Hours()/24))
+}
+
+// formatInodes formats an inode count compactly, e.g. "350k" or "6.5M"
+func formatInodes(n uint64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1000:
+		return fmt.Sprintf("%.0fk", float64(n)/1000)
+	}
+	return fmt.Sprint(n)
+}
+
+// usagePercentText formats a usage percentage, in red from
+// filesystemFullPercent
+func usagePercentText(percent float64) string {
+	text := fmt.Sprintf("%5.1f%%", percent)
+	if percent >= filesystemFullPercent {
+		return fmt.Sprintf("[%s](fg:crit)", text)
+	}
+	return text
+}
+
+// FilesystemPanel lists the mounted filesystems with their space and inode
+// usage, and how soon those that are filling up will be full. It is drawn
+// over the disk section while open. Usage is collected for the whole
+// session, so the projections cover more than the time the panel is open.
+type FilesystemPanel struct {
+	*widgets.Paragraph
+	Active    bool
+	collector *FilesystemCollector
+	trends    fillTrends
+	last      *filesystemSnapshot // Latest snapshot, shown on opening, or nil before the first
+}
+
+func createFilesystemPanel() *FilesystemPanel {
+	p := &FilesystemPanel{
+		Paragraph: widgets.NewParagraph(),
+		collector: NewFilesystemCollector(),
+		trends:    make(fillTrends),
+	}
+	p.Title = "Filesystems (U to close)"
+	p.Text = "[Collecting...](fg:warn)"
+	p.Border = true
+	p.WrapText = false
+	p.TitleStyle.Fg = theme.Title
+	p.BorderStyle.Fg = theme.Panel
+	return p
+}
+
+// Start begins collecting, for the rest of the session
+func (p *FilesystemPanel) Start() {
+	p.collector.Start()
+}
+
+// Stop ends collecting
+func (p *FilesystemPanel) Stop() {
+	p.collector.Stop()
+}
+
+// Open shows the panel with the latest snapshot, laid out for its
+// current size
+func (p *FilesystemPanel) Open() {
+	p.Active = true
+	if p.last != nil {
+		p.render(*p.last)
+	}
+}
+
+// Close hides the panel
+func (p *FilesystemPanel) Close() {
+	p.Active = false
+}
+
+// Snapshots returns the channel the collector publishes on
+func (p *FilesystemPanel) Snapshots() <-chan filesystemSnapshot {
+	return p.collector.Snapshots
+}
+
+// Update records a new snapshot in the trends and shows it
+func (p *FilesystemPanel) Update(snap filesystemSnapshot) {
+	if snap.Err == nil {
+		p.trends.Add(snap, time.Now())
+	}
+	p.last = &snap
+	p.render(snap)
+}
+
+// render shows a snapshot, listing nearly full filesystems first so they
+// stay visible when the rest don't fit
+func (p *FilesystemPanel) render(snap filesystemSnapshot) {
+	p.Title = fmt.Sprintf("Filesystems: %d (U to close)", len(snap.Filesystems))
+	if snap.Err != nil {
+		p.Title = "Filesystems (U to close)"
+		p.Text = fmt.Sprintf("[Error listing filesystems: %v](fg:crit)", snap.Err)
+		return
+	}
+
+	filesystems := append([]filesystem(nil), snap.Filesystems...)
+	sort.SliceStable(filesystems, func(i, j int) bool {
+		return filesystems[i].Full() && !filesystems[j].Full()
+	})
+
+	rows := p.Inner.Dy() - 1
+	lines := []string{fmt.Sprintf("[%-*s %-8s %9s %9s %6s  %7s %7s %6s  %s](fg:label)",
+		filesystemMountWidth, "Mount", "Type", "Size", "Used", "Use%", "Inodes", "IUsed", "IUse%", "Full in")}
+	for i, f := range filesystems {
+		if i == rows-1 && len(filesystems) > rows {
+			lines = append(lines, fmt.Sprintf("[... %d more](fg:text)", len(filesystems)-i))
+			break
+		}
+		inodes, inodesUsed, inodePercent := "-", "-", "     -"
+		if f.InodesTotal > 0 {
+			inodes = formatInodes(f.InodesTotal)
+			inodesUsed = formatInodes(f.InodesUsed)
+			inodePercent = usagePercentText(f.InodesUsedPercent)
+		}
+		fullIn := ""
+		if d, ok := p.trends.TimeToFull(f); ok {
+			fullIn = fmt.Sprintf("[%s](fg:crit)", formatTimeToFull(d))
+		}
+		lines = append(lines, fmt.Sprintf("%-*s %-8s %9s %9s %s  %7s %7s %s  %s",
+			filesystemMountWidth, truncateToWidth(f.Mountpoint, filesystemMountWidth),
+			truncateToWidth(f.Fstype, 8), formatBytes(f.Total), formatBytes(f.Used),
+			usagePercentText(f.UsedPercent), inodes, inodesUsed, inodePercent, fullIn))
+	}
+	if len(filesystems) == 0 {
+		lines = append(lines, "No filesystems")
+	}
+	p.Text = strings.Join(lines, "\n")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// statusDuration is how long a transient footer message stays visible
+const statusDuration = 3 * time.Second
+
+// StatusFooter shows key hints along the bottom row and can temporarily
+// replace them with a status message
+type StatusFooter struct {
+	*widgets.Paragraph
+	DefaultText string    // Text restored once a status message expires
+	expires     time.Time // When the current status message should be cleared
+}
+
+func createStatusFooter(text string) *StatusFooter {
+	f := &StatusFooter{
+		Paragraph:   widgets.NewParagraph(),
+		DefaultText: text,
+	}
+	f.Border = false
+	f.Text = text
+	return f
+}
+
+// SetStatus shows text in place of the key hints for statusDuration
+func (f *StatusFooter) SetStatus(text string) {
+	f.Text = text
+	f.expires = time.Now().Add(statusDuration)
+}
+
+// expireStatus restores the default text once the status message has timed
+// out. It reports whether the footer changed and needs to be re-rendered.
+func (f *StatusFooter) expireStatus() bool {
+	if f.expires.IsZero() || time.Now().Before(f.expires) {
+		return false
+	}
+	f.Text = f.DefaultText
+	f.expires = time.Time{}
+	return true
+}
+
+// Reset drops any status message or input prompt and shows the key hints
+func (f *StatusFooter) Reset() {
+	f.Text = f.DefaultText
+	f.expires = time.Time{}
+}
+
+// ShowInput renders in's prompt and current value with a cursor
+func (f *StatusFooter) ShowInput(in *LineInput) {
+	f.Text = fmt.Sprintf("[%s](fg:accent)%s_", in.Prompt, in.Value)
+	f.expires = time.Time{}
+}
+
+// LineInput is a single-line text input edited through key events
+type LineInput struct {
+	Active bool   // Whether the input is open and capturing keys
+	Prompt string // Label shown before the value
+	Value  string // Text entered so far
+}
+
+// Open activates the input with an initial value
+func (in *LineInput) Open(value string) {
+	in.Active = true
+	in.Value = value
+}
+
+// Close deactivates the input, keeping its value
+func (in *LineInput) Close() {
+	in.Active = false
+}
+
+// HandleKey applies a termui key ID to the value and reports whether the
+// value changed
+func (in *LineInput) HandleKey(id string) bool {
+	switch id {
+	case "<Backspace>", "<C-<Backspace>>":
+		if in.Value == "" {
+			return false
+		}
+		_, size := utf8.DecodeLastRuneInString(in.Value)
+		in.Value = in.Value[:len(in.Value)-size]
+		return true
+	case "<Space>":
+		in.Value += " "
+		return true
+	}
+	// Printable keys arrive as the character itself; named keys look like <Tab>
+	if utf8.RuneCountInString(id) != 1 {
+		return false
+	}
+	in.Value += id
+	return true
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// freeSpaceHistoryLen is how many samples the free space graph keeps, an
+// hour at filesystemInterval
+const freeSpaceHistoryLen = 360
+
+// freeSpaceMinSpan is the narrowest range, in GB, the free space graph
+// scales to, so that a mount whose free space barely moves plots as a
+// flat line rather than magnified noise
+const freeSpaceMinSpan = 0.1
+
+// FreeSpaceGraph plots one mount's free space over the session, drawn in
+// place of the disk I/O graph while shown. It is fed every filesystem
+// snapshot whether shown or not, so the history is there when it's opened.
+type FreeSpaceGraph struct {
+	*widgets.Plot
+	Active  bool
+	Mount   string    // Mount point being plotted
+	history []float64 // Free GB per sample, oldest first
+	mounts  []string  // Mount points in the last snapshot, sorted
+}
+
+func createFreeSpaceGraph(mount string) *FreeSpaceGraph {
+	g := &FreeSpaceGraph{Plot: widgets.NewPlot(), Mount: mount}
+	g.Border = true
+	g.LineColors[0] = theme.Line
+	g.DrawDirection = widgets.DrawRight
+	g.TitleStyle.Fg = theme.Title
+	g.PlotType = widgets.LineChart
+	g.ShowAxes = false
+	g.HorizontalScale = 1.0
+	g.AxesColor = ui.ColorClear
+	return g
+}
+
+// Add records the plotted mount's free space from a snapshot
+func (g *FreeSpaceGraph) Add(snap filesystemSnapshot) {
+	if snap.Err != nil {
+		return
+	}
+	g.mounts = g.mounts[:0]
+	for _, f := range snap.Filesystems {
+		g.mounts = append(g.mounts, f.Mountpoint)
+		if f.Mountpoint != g.Mount {
+			continue
+		}
+		if len(g.history) == freeSpaceHistoryLen {
+			g.history = g.history[1:]
+		}
+		g.history = append(g.history, float64(f.Total-f.Used)/1024/1024/1024)
+	}
+	sort.Strings(g.mounts)
+}
+
+// Cycle switches to the next mount point, starting its history over
+func (g *FreeSpaceGraph) Cycle() {
+	if len(g.mounts) == 0 {
+		return
+	}
+	next := sort.SearchStrings(g.mounts, g.Mount)
+	if next < len(g.mounts) && g.mounts[next] == g.Mount {
+		next++
+	}
+	g.Mount = g.mounts[next%len(g.mounts)]
+	g.history = nil
+}
+
+// Draw plots as much of the history as fits, scaled to the range it spans,
+// and titles the graph with that range. Plots always start at zero, which
+// would flatten a few GB draining from a large disk, so the graph shows
+// free space above the lowest point in view.
+func (g *FreeSpaceGraph) Draw(buf *ui.Buffer) {
+	window := g.history
+	if fit := g.Inner.Dx(); fit > 1 && len(window) > fit {
+		window = window[len(window)-fit:]
+	}
+	if len(window) == 0 {
+		g.Title = fmt.Sprintf("Free Space on %s (no samples yet; E for next mount)", g.Mount)
+		g.Data = [][]float64{{0, 0}}
+		g.MaxVal = 1
+		g.Plot.Draw(buf)
+		return
+	}
+
+	low, high := window[0], window[0]
+	for _, v := range window {
+		low = min(low, v)
+		high = max(high, v)
+	}
+	if high-low < freeSpaceMinSpan {
+		low = max(0, high-freeSpaceMinSpan)
+	}
+	data := make([]float64, len(window), len(window)+1)
+	for i, v := range window {
+		data[i] = v - low
+	}
+	// A line needs two points
+	if len(data) == 1 {
+		data = append(data, data[0])
+	}
+	g.Data = [][]float64{data}
+	g.MaxVal = max(high-low, freeSpaceMinSpan)
+
+	minutes := int(time.Duration(len(window)) * filesystemInterval / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	g.Title = fmt.Sprintf("Free Space on %s: %.2f GB (%.2f–%.2f GB over the last ~%d min; E for next mount)",
+		g.Mount, window[len(window)-1], low, high, minutes)
+	g.Plot.Draw(buf)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// baseFrequency is the nominal clock speed in MHz reported by cpu.Info, read
+// once since it never changes. It is 0 when the platform doesn't report one.
+var baseFrequency = sync.OnceValue(func() float64 {
+	info, err := cpu.Info()
+	if err != nil || len(info) == 0 {
+		return 0
+	}
+	return info[0].Mhz
+})
+
+// cpuFrequencies returns the current clock speed in MHz of each of count
+// logical cores. On Linux it reads cpufreq's scaling_cur_freq, which is a
+// cheap sysfs read; cores without it, and other platforms, get the base
+// frequency instead. Unknown frequencies are 0.
+func cpuFrequencies(count int) []float64 {
+	freqs := make([]float64, count)
+	for i := range freqs {
+		freqs[i] = coreFrequency(i)
+	}
+	return freqs
+}
+
+func coreFrequency(core int) float64 {
+	if runtime.GOOS == "linux" {
+		data, err := os.ReadFile(fmt.Sprintf("/sys/devices/system/cpu/cpu%d/cpufreq/scaling_cur_freq", core))
+		if err == nil {
+			// The value is in kHz
+			if khz, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64); err == nil {
+				return khz / 1000
+			}
+		}
+	}
+	return baseFrequency()
+}
+
+// formatFrequency renders a clock speed in MHz as e.g. "3.8GHz"
+func formatFrequency(mhz float64) string {
+	if mhz >= 1000 {
+		return fmt.Sprintf("%.1fGHz", mhz/1000)
+	}
+	return fmt.Sprintf("%.0fMHz", mhz)
+}
@@ -4,15 +4,15 @@ go 1.22.5
 
 require (
 	github.com/gizak/termui/v3 v3.1.0
+	github.com/mattn/go-runewidth v0.0.2
+	github.com/nsf/termbox-go v0.0.0-20190121233118-02980233997d
 	github.com/shirou/gopsutil/v3 v3.22.5
 )
 
 require (
 	github.com/go-ole/go-ole v1.2.6 // indirect
 	github.com/lufia/plan9stats v0.0.0-20211012122336-39d0f177ccd0 // indirect
-	github.com/mattn/go-runewidth v0.0.2 // indirect
 	github.com/mitchellh/go-wordwrap v0.0.0-20150314170334-ad45545899c7 // indirect
-	github.com/nsf/termbox-go v0.0.0-20190121233118-02980233997d // indirect
 	github.com/power-devops/perfstat v0.0.0-20210106213030-5aafc221ea8c // indirect
 	github.com/tklauser/go-sysconf v0.3.10 // indirect
 	github.com/tklauser/numcpus v0.4.0 // indirect
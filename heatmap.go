@@ -0,0 +1,69 @@
+package main
+
+import (
+	"image"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+// heatmapCellWidth is how many columns each core takes in the heatmap: a
+// two-cell block and a gap
+const heatmapCellWidth = 3
+
+// Heatmap draws one colored block per core, packed into as few rows as the
+// width allows, which stays readable on machines with 100+ cores
+type Heatmap struct {
+	ui.Block
+	Values     []float64       // Utilization of each core, 0-100
+	Thresholds usageThresholds // Where the ramp reaches yellow and red
+}
+
+func NewHeatmap() *Heatmap {
+	return &Heatmap{Block: *ui.NewBlock()}
+}
+
+// heatmapRows returns how many rows count cores need in a heatmap width
+// cells wide, including its border
+func heatmapRows(count, width int) int {
+	perRow := (width - 2) / heatmapCellWidth
+	if perRow < 1 {
+		perRow = 1
+	}
+	return (count + perRow - 1) / perRow
+}
+
+func (h *Heatmap) Draw(buf *ui.Buffer) {
+	h.Block.Draw(buf)
+	perRow := h.Inner.Dx() / heatmapCellWidth
+	if perRow < 1 {
+		return
+	}
+	for i, v := range h.Values {
+		x := h.Inner.Min.X + (i%perRow)*heatmapCellWidth
+		y := h.Inner.Min.Y + i/perRow
+		if y >= h.Inner.Max.Y {
+			break
+		}
+		cell := ui.NewCell('█', ui.NewStyle(h.color(v)))
+		buf.SetCell(cell, image.Pt(x, y))
+		buf.SetCell(cell, image.Pt(x+1, y))
+	}
+}
+
+// color picks a shade of the theme's heat ramp for a utilization
+// percentage: the first five below the warning threshold, the next five up
+// to the critical one, then the last
+func (h *Heatmap) color(percent float64) ui.Color {
+	heatRamp := theme.Heat
+	const warnStart, critStart = 5, 10 // Indexes of the first yellow and of red
+	t := h.Thresholds
+	switch {
+	case percent >= t.Crit:
+		return heatRamp[critStart]
+	case percent >= t.Warn:
+		return heatRamp[warnStart+int((percent-t.Warn)/(t.Crit-t.Warn)*(critStart-warnStart))]
+	case percent <= 0 || t.Warn <= 0:
+		return heatRamp[0]
+	}
+	return heatRamp[int(percent/t.Warn*warnStart)]
+}
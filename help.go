@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// helpColumnGap is the space between the help overlay's columns
+const helpColumnGap = 3
+
+// HelpPanel is an overlay listing every key binding by section, after the
+// version and settings that aren't visible elsewhere, such as which
+// interfaces the network totals leave out. Any key closes it.
+type HelpPanel struct {
+	*widgets.Paragraph
+	Active bool     // Whether the panel is open
+	info   []string // Lines above the bindings
+	width  int      // Widest info line, in cells
+}
+
+func createHelpPanel() *HelpPanel {
+	h := &HelpPanel{Paragraph: widgets.NewParagraph()}
+	h.Title = "SysGoMon Help (any key to close)"
+	h.Border = true
+	h.WrapText = false
+	h.TitleStyle.Fg = theme.Title
+	h.BorderStyle.Fg = theme.Panel
+	return h
+}
+
+// Open shows the panel describing sel, centered within area
+func (h *HelpPanel) Open(sel *netSelection, area image.Rectangle) {
+	h.Active = true
+
+	counted := "every interface (--all-interfaces)"
+	switch {
+	case len(sel.Patterns) > 0:
+		counted = "interfaces matching --iface " + strings.Join(sel.Patterns, ", ")
+	case len(sel.Exclude) > 0:
+		counted = "every interface but " + strings.Join(sel.Exclude, ", ")
+	}
+	h.info = []string{
+		detailLine("Version", version),
+		detailLine("Network totals", counted),
+	}
+	h.width = len("Network totals: ") + len(counted)
+	if len(sel.Exclude) > 0 {
+		note := "  Excluded interfaces are greyed in the interface table; --all-interfaces counts them"
+		h.info = append(h.info, note)
+		if len(note) > h.width {
+			h.width = len(note)
+		}
+	}
+	h.Center(area)
+}
+
+// Center lays the bindings out in as few columns as fit area's height and
+// positions the panel in the middle of area
+func (h *HelpPanel) Center(area image.Rectangle) {
+	keyWidth, helpWidth := 0, 0
+	for _, b := range keyBindings {
+		if w := len(b.Label()); w > keyWidth {
+			keyWidth = w
+		}
+		if w := len(b.Help); w > helpWidth {
+			helpWidth = w
+		}
+	}
+	columnWidth := keyWidth + 1 + helpWidth
+
+	// Each line is padded to columnWidth so the columns line up
+	blank := strings.Repeat(" ", columnWidth)
+	var lines []string
+	section := ""
+	for _, b := range keyBindings {
+		if b.Section != section {
+			if section != "" {
+				lines = append(lines, blank)
+			}
+			section = b.Section
+			lines = append(lines, fmt.Sprintf("[%-*s](fg:label)", columnWidth, section))
+		}
+		lines = append(lines, fmt.Sprintf("[%-*s](fg:accent) %-*s", keyWidth, b.Label(), helpWidth, b.Help))
+	}
+
+	maxColumns := (area.Dx() - 2 + helpColumnGap) / (columnWidth + helpColumnGap)
+	if maxColumns < 1 {
+		maxColumns = 1
+	}
+	fitRows := area.Dy() - 2 - len(h.info) - 1
+	var columns [][]string
+	for n := 1; n <= maxColumns; n++ {
+		columns = helpColumns(lines, blank, n)
+		if len(columns[0]) <= fitRows {
+			break
+		}
+	}
+
+	text := append([]string{}, h.info...)
+	text = append(text, "")
+	gap := strings.Repeat(" ", helpColumnGap)
+	for row := range columns[0] {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = blank
+			if row < len(column) {
+				cells[i] = column[row]
+			}
+		}
+		text = append(text, strings.Join(cells, gap))
+	}
+	h.Text = strings.Join(text, "\n")
+
+	width := len(columns)*(columnWidth+helpColumnGap) - helpColumnGap
+	if h.width > width {
+		width = h.width
+	}
+	width = min(width+2, area.Dx())
+	height := min(len(text)+2, area.Dy())
+	x := area.Min.X + (area.Dx()-width)/2
+	y := area.Min.Y + (area.Dy()-height)/2
+	h.SetRect(x, y, x+width, y+height)
+}
+
+// helpColumns pours lines into n columns of equal height, taking more rows
+// where needed so no column starts with a blank line or ends with a
+// section heading
+func helpColumns(lines []string, blank string, n int) [][]string {
+	for rows := (len(lines) + n - 1) / n; ; rows++ {
+		columns := [][]string{nil}
+		for _, line := range lines {
+			current := columns[len(columns)-1]
+			heading := strings.HasSuffix(line, "](fg:label)")
+			if len(current) == rows || (heading && len(current) == rows-1) {
+				columns = append(columns, nil)
+				current = nil
+			}
+			if line == blank && len(current) == 0 {
+				continue
+			}
+			columns[len(columns)-1] = append(current, line)
+		}
+		if len(columns) <= n {
+			return columns
+		}
+	}
+}
+
+// Close hides the panel
+func (h *HelpPanel) Close() {
+	h.Active = false
+}
@@ -0,0 +1,198 @@
+package main
+
+import "strings"
+
+// keyAction is what a key press does outside the overlays that capture
+// keys of their own (the kill prompt, column menu, and filter input)
+type keyAction int
+
+const (
+	actNone keyAction = iota
+	actQuit
+	actHelp
+	actPause
+	actEscape
+	actToggleCPU
+	actToggleNetwork
+	actToggleDisk
+	actToggleProcesses
+	actCoreView
+	actReadSensors
+	actNextInterface
+	actNetUnits
+	actIdleInterfaces
+	actResetSession
+	actNetProcs
+	actListeners
+	actNextDisk
+	actFilesystems
+	actRAID
+	actFreeSpace
+	actNextMount
+	actSplitGraphs
+	actNetLogScale
+	actDiskLogScale
+	actUp
+	actDown
+	actPageUp
+	actPageDown
+	actTop
+	actBottom
+	actDetails
+	actFilter
+	actKill
+	actCopy
+	actJump
+	actPin
+	actFreeze
+	actGroup
+	actContainers
+	actOwner
+	actUserView
+	actKernel
+	actCommandMode
+	actIOColumns
+	actMemoryUnit
+	actMemoryView
+	actColumnMenu
+	actSortCPU
+	actSortMemory
+	actSortPID
+	actSortName
+	actSortThreads
+	actSortTime
+	actSortAge
+	actSortIO
+	actSortFDs
+	actSortOOM
+	actResize // Terminal resized; not bound to a key
+)
+
+// keyBinding ties keys to an action and describes them in the help overlay.
+// Keys are termui event IDs, e.g. "q" or "<C-c>"; where a binding has more
+// than one, the handler tells them apart by the event ID.
+type keyBinding struct {
+	Keys    []string
+	Action  keyAction
+	Section string // Heading the binding is listed under
+	Help    string // What the keys do, kept short for the overlay's columns
+}
+
+// keyBindings lists every key the main loop handles, in the order the help
+// overlay shows them
+var keyBindings = []keyBinding{
+	{[]string{"q", "<C-c>"}, actQuit, "Global", "Quit"},
+	{[]string{"?"}, actHelp, "Global", "Show this help"},
+	{[]string{"h"}, actPause, "Global", "Pause or resume all updates"},
+	{[]string{"<Escape>"}, actEscape, "Global", "Close details, clear the filter"},
+	{[]string{"1"}, actToggleCPU, "Global", "Collapse, hide, or show the CPU"},
+	{[]string{"2"}, actToggleNetwork, "Global", "Hide or show the network"},
+	{[]string{"3"}, actToggleDisk, "Global", "Hide or show the disk section"},
+	{[]string{"4"}, actToggleProcesses, "Global", "Hide or show the process list"},
+
+	{[]string{"s"}, actCoreView, "CPU & memory", "Cycle gauges/sparklines/heatmap"},
+	{[]string{"R"}, actReadSensors, "CPU & memory", "Re-read the temperature sensors"},
+
+	{[]string{"N"}, actNextInterface, "Network", "Cycle interfaces, all or one"},
+	{[]string{"b"}, actNetUnits, "Network", "Switch between bits and bytes"},
+	{[]string{"I"}, actIdleInterfaces, "Network", "Show or hide idle interfaces"},
+	{[]string{"z"}, actResetSession, "Network", "Reset the session totals"},
+	{[]string{"B"}, actNetProcs, "Network", "Show or hide network processes"},
+	{[]string{"L"}, actListeners, "Network", "Show or hide listening ports"},
+
+	{[]string{"d"}, actNextDisk, "Disk", "Cycle disks, all or one"},
+	{[]string{"U"}, actFilesystems, "Disk", "Show or hide filesystem usage"},
+	{[]string{"A"}, actRAID, "Disk", "Show or hide RAID arrays"},
+	{[]string{"S"}, actFreeSpace, "Disk", "Show or hide free space graph"},
+	{[]string{"E"}, actNextMount, "Disk", "Graph the next mount"},
+
+	{[]string{"V"}, actSplitGraphs, "Graphs", "Split or combine in/out graphs"},
+	{[]string{"G"}, actNetLogScale, "Graphs", "Network graph log scale on/off"},
+	{[]string{"D"}, actDiskLogScale, "Graphs", "Disk graph log scale on/off"},
+
+	{[]string{"<Up>"}, actUp, "Process list", "Move the selection up"},
+	{[]string{"<Down>"}, actDown, "Process list", "Move the selection down"},
+	{[]string{"<PageUp>"}, actPageUp, "Process list", "Scroll up a page"},
+	{[]string{"<PageDown>"}, actPageDown, "Process list", "Scroll down a page"},
+	{[]string{"<Home>"}, actTop, "Process list", "Jump to the first process"},
+	{[]string{"<End>"}, actBottom, "Process list", "Jump to the last process"},
+	{[]string{"<Enter>"}, actDetails, "Process list", "Show or hide process details"},
+	{[]string{"/"}, actFilter, "Process list", "Filter by name or command"},
+	{[]string{"k", "K"}, actKill, "Process list", "SIGTERM / SIGKILL the process"},
+	{[]string{"<C-k>"}, actKill, "Process list", "Kill it and its descendants"},
+	{[]string{"y", "Y"}, actCopy, "Process list", "Copy the PID / command line"},
+	{[]string{"P", "C"}, actJump, "Process list", "Jump to the parent / children"},
+	{[]string{"<Space>"}, actPin, "Process list", "Pin or unpin the process"},
+	{[]string{"f"}, actFreeze, "Process list", "Freeze or unfreeze the row order"},
+	{[]string{"g"}, actGroup, "Process list", "Group processes by name"},
+	{[]string{"x"}, actContainers, "Process list", "Only processes in containers"},
+	{[]string{"o"}, actOwner, "Process list", "Only your own processes"},
+	{[]string{"u"}, actUserView, "Process list", "Switch to a per-user summary"},
+	{[]string{"H"}, actKernel, "Process list", "Show or hide kernel threads"},
+	{[]string{"e"}, actCommandMode, "Process list", "Cycle the Command column's form"},
+	{[]string{"i"}, actIOColumns, "Process list", "Show or hide disk I/O columns"},
+	{[]string{"M"}, actMemoryUnit, "Process list", "Show memory as percent or bytes"},
+	{[]string{"W"}, actMemoryView, "Process list", "Switch to the memory view"},
+	{[]string{"<F2>"}, actColumnMenu, "Process list", "Choose the columns shown"},
+
+	{[]string{"c"}, actSortCPU, "Sorting", "By CPU (again to reverse)"},
+	{[]string{"m"}, actSortMemory, "Sorting", "By memory"},
+	{[]string{"p"}, actSortPID, "Sorting", "By PID"},
+	{[]string{"n"}, actSortName, "Sorting", "By name"},
+	{[]string{"t"}, actSortThreads, "Sorting", "By thread count"},
+	{[]string{"T"}, actSortTime, "Sorting", "By cumulative CPU time"},
+	{[]string{"a"}, actSortAge, "Sorting", "By age"},
+	{[]string{"r", "w"}, actSortIO, "Sorting", "By disk read / write (I/O shown)"},
+	{[]string{"F"}, actSortFDs, "Sorting", "By open files (fds shown)"},
+	{[]string{"O"}, actSortOOM, "Sorting", "By OOM score (oom shown)"},
+}
+
+// headingSections maps the headings whose keys act on a single section to
+// that section, so the keys can be refused while it's hidden
+var headingSections = map[string]section{
+	"Network":      sectionNetwork,
+	"Disk":         sectionDisk,
+	"Process list": sectionProcesses,
+	"Sorting":      sectionProcesses,
+}
+
+// boundKeys maps each bound event ID to its binding, for the main loop
+var boundKeys = bindingsByKey(keyBindings)
+
+func bindingsByKey(bindings []keyBinding) map[string]keyBinding {
+	byKey := make(map[string]keyBinding)
+	for _, b := range bindings {
+		for _, key := range b.Keys {
+			byKey[key] = b
+		}
+	}
+	return byKey
+}
+
+// keyNames spells out termui's special event IDs the way the README does
+var keyNames = map[string]string{
+	"<C-c>":      "Ctrl+C",
+	"<C-k>":      "Ctrl+K",
+	"<Escape>":   "Escape",
+	"<Enter>":    "Enter",
+	"<Space>":    "Space",
+	"<Up>":       "Up",
+	"<Down>":     "Down",
+	"<PageUp>":   "PgUp",
+	"<PageDown>": "PgDn",
+	"<Home>":     "Home",
+	"<End>":      "End",
+	"<F2>":       "F2",
+}
+
+// Label names the binding's keys for display, e.g. "q / Ctrl+C"
+func (b keyBinding) Label() string {
+	names := make([]string, len(b.Keys))
+	for i, key := range b.Keys {
+		names[i] = key
+		if name, ok := keyNames[key]; ok {
+			names[i] = name
+		}
+	}
+	return strings.Join(names, " / ")
+}
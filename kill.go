@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// KillPrompt is a confirmation overlay shown before signalling a process
+type KillPrompt struct {
+	*widgets.Paragraph
+	Active bool   // Whether the prompt is open and capturing keys
+	PID    int32  // Process to signal
+	Name   string // Process name shown in the prompt
+	Force  bool   // Send SIGKILL instead of SIGTERM
+	Tree   bool   // Kill the process and all of its descendants
+}
+
+func createKillPrompt() *KillPrompt {
+	kp := &KillPrompt{
+		Paragraph: widgets.NewParagraph(),
+	}
+	kp.Title = "Confirm"
+	kp.Border = true
+	kp.BorderStyle.Fg = theme.Crit
+	kp.TitleStyle.Fg = theme.Title
+	return kp
+}
+
+// Open activates the prompt for the given process, centered within area.
+// With tree set the process's descendants are killed too, and force is
+// ignored since survivors are escalated to SIGKILL anyway.
+func (kp *KillPrompt) Open(info ProcessInfo, force, tree bool, area image.Rectangle) {
+	kp.Active = true
+	kp.PID = info.PID
+	kp.Name = info.Name
+	kp.Force = force && !tree
+	kp.Tree = tree
+
+	switch {
+	case tree:
+		kp.Text = fmt.Sprintf("Kill %s (PID %d) and all its children? y/n", info.Name, info.PID)
+	case force:
+		kp.Text = fmt.Sprintf("Force kill %s (PID %d)? y/n", info.Name, info.PID)
+	default:
+		kp.Text = fmt.Sprintf("Kill %s (PID %d)? y/n", info.Name, info.PID)
+	}
+	kp.Center(area)
+}
+
+// Center positions the prompt in the middle of area
+func (kp *KillPrompt) Center(area image.Rectangle) {
+	width := len(kp.Text) + 4
+	if width > area.Dx() {
+		width = area.Dx()
+	}
+	x := area.Min.X + (area.Dx()-width)/2
+	y := area.Min.Y + (area.Dy()-3)/2
+	kp.SetRect(x, y, x+width, y+3)
+}
+
+// Close dismisses the prompt without signalling anything
+func (kp *KillPrompt) Close() {
+	kp.Active = false
+}
+
+// Confirm sends SIGTERM (or SIGKILL when Force is set) to the prompted
+// process and closes the prompt
+func (kp *KillPrompt) Confirm() error {
+	kp.Close()
+
+	p, err := process.NewProcess(kp.PID)
+	if err != nil {
+		return err
+	}
+	if kp.Force {
+		return p.Kill()
+	}
+	return p.Terminate()
+}
+
+// killTreeGrace is how long processes get to exit after SIGTERM before a
+// tree kill escalates to SIGKILL
+const killTreeGrace = 500 * time.Millisecond
+
+// killTreeResult reports the outcome of killing a process tree
+type killTreeResult struct {
+	PID       int32  // Root of the tree
+	Name      string // Name of the root process
+	Signalled int    // Processes that were sent a signal
+	Failed    int    // Processes that could not be signalled and are still running
+}
+
+// ConfirmTree closes the prompt and kills the prompted process tree on a
+// separate goroutine, since it waits for processes to exit. The outcome is
+// sent on results.
+func (kp *KillPrompt) ConfirmTree(results chan<- killTreeResult) {
+	kp.Close()
+	pid, name := kp.PID, kp.Name
+	go func() {
+		result := killTree(pid)
+		result.Name = name
+		results <- result
+	}()
+}
+
+// killTree sends SIGTERM to pid and all its descendants, deepest first so
+// parents can't replace children that are being killed, then sends SIGKILL
+// to whatever is still running after killTreeGrace. Processes that exit
+// while the tree is being walked are skipped rather than counted as failures.
+// sysgomon itself is never signalled, even when it runs inside the tree.
+func killTree(pid int32) killTreeResult {
+	result := killTreeResult{PID: pid}
+	if _, err := process.NewProcess(pid); err != nil {
+		result.Failed++
+		return result
+	}
+
+	children, err := processChildren()
+	if err != nil {
+		result.Failed++
+		return result
+	}
+	var order []*process.Process // Descendants before their parents
+	for _, member := range killOrder(pid, children, int32(os.Getpid())) {
+		// Skip processes that exited since the snapshot
+		if p, err := process.NewProcess(member); err == nil {
+			order = append(order, p)
+		}
+	}
+
+	signalled := make([]*process.Process, 0, len(order))
+	for _, p := range order {
+		if err := p.Terminate(); err != nil {
+			if running, _ := p.IsRunning(); running {
+				result.Failed++
+			}
+			continue
+		}
+		signalled = append(signalled, p)
+	}
+	result.Signalled = len(signalled)
+
+	time.Sleep(killTreeGrace)
+	for _, p := range signalled {
+		if running, _ := p.IsRunning(); !running {
+			continue
+		}
+		if err := p.Kill(); err != nil {
+			if running, _ := p.IsRunning(); running {
+				result.Failed++
+			}
+		}
+	}
+	return result
+}
+
+// processChildren maps each PID to its children's PIDs, from a single pass
+// over the process table. Processes that exit during the pass are left out
+// without affecting the rest.
+func processChildren() (map[int32][]int32, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[int32][]int32, len(procs))
+	for _, p := range procs {
+		ppid, err := p.Ppid()
+		if err != nil {
+			continue
+		}
+		children[ppid] = append(children[ppid], p.Pid)
+	}
+	return children, nil
+}
+
+// killOrder returns pid and its descendants in children, deepest first so
+// parents come after their children. self is left out.
+func killOrder(pid int32, children map[int32][]int32, self int32) []int32 {
+	seen := make(map[int32]bool)
+	var order []int32
+	var walk func(pid int32)
+	walk = func(pid int32) {
+		if seen[pid] {
+			return
+		}
+		seen[pid] = true
+		for _, child := range children[pid] {
+			walk(child)
+		}
+		if pid != self {
+			order = append(order, pid)
+		}
+	}
+	walk(pid)
+	return order
+}
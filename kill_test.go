@@ -0,0 +1,38 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestKillOrder(t *testing.T) {
+	// 1 ─┬─ 2 ── 4
+	//    ├─ 3
+	//    └─ 5 (sysgomon) ── 6
+	children := map[int32][]int32{
+		1: {2, 3, 5},
+		2: {4},
+		5: {6},
+		9: {10}, // Unrelated tree
+	}
+	tests := []struct {
+		name string
+		pid  int32
+		self int32
+		want []int32
+	}{
+		{"whole tree", 1, 0, []int32{4, 2, 3, 6, 5, 1}},
+		{"subtree", 2, 0, []int32{4, 2}},
+		{"leaf", 3, 0, []int32{3}},
+		{"skips self", 1, 5, []int32{4, 2, 3, 6, 1}},
+		// A PID missing from the snapshot is still returned on its own
+		{"unknown pid", 42, 0, []int32{42}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := killOrder(tt.pid, children, tt.self); !slices.Equal(got, tt.want) {
+				t.Errorf("killOrder(%d) = %v, want %v", tt.pid, got, tt.want)
+			}
+		})
+	}
+}
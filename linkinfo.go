@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// linkInfoInterval is how often an interface's link details are re-read.
+// Finding a wireless interface's network runs an external command, and link
+// speed only changes when the link is renegotiated.
+const linkInfoInterval = 10 * time.Second
+
+// linkInfo is an interface's negotiated speed and, for wireless interfaces,
+// the network it is joined to. Fields the platform can't report, or that
+// don't apply to the interface, are left zero.
+type linkInfo struct {
+	SpeedMbps int    // Negotiated link speed, or 0 when unknown
+	Wireless  bool   // Whether the interface is a wireless one
+	SSID      string // Wireless network name, or "" when unknown
+	SignalDBm int    // Wireless signal level, or 0 when unknown
+}
+
+// Short returns the link details for the interface table, e.g. "1G" or
+// "-56dBm", or "" when there are none
+func (l linkInfo) Short() string {
+	switch {
+	case l.Wireless && l.SignalDBm != 0:
+		return fmt.Sprintf("%ddBm", l.SignalDBm)
+	case l.SpeedMbps > 0:
+		return formatLinkSpeed(l.SpeedMbps)
+	}
+	return ""
+}
+
+// String returns the link details for the network section's title, e.g.
+// "1G" or "HomeNet -56 dBm", or "" when there are none
+func (l linkInfo) String() string {
+	var parts []string
+	if l.SpeedMbps > 0 {
+		parts = append(parts, formatLinkSpeed(l.SpeedMbps))
+	}
+	if l.SSID != "" {
+		parts = append(parts, l.SSID)
+	}
+	if l.SignalDBm != 0 {
+		parts = append(parts, fmt.Sprintf("%d dBm", l.SignalDBm))
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatLinkSpeed formats a link speed in Mbps, e.g. "100M", "1G", or "2.5G"
+func formatLinkSpeed(mbps int) string {
+	if mbps < 1000 {
+		return fmt.Sprintf("%dM", mbps)
+	}
+	return strconv.FormatFloat(float64(mbps)/1000, 'f', -1, 64) + "G"
+}
+
+// linkCache keeps each interface's link details, re-reading them at most
+// every linkInfoInterval
+type linkCache struct {
+	infos map[string]linkInfo
+	read  map[string]time.Time // When each interface's details were last read
+}
+
+func newLinkCache() *linkCache {
+	return &linkCache{
+		infos: make(map[string]linkInfo),
+		read:  make(map[string]time.Time),
+	}
+}
+
+// Get returns the link details of the interface called name
+func (c *linkCache) Get(name string) linkInfo {
+	if time.Since(c.read[name]) >= linkInfoInterval {
+		c.infos[name] = readLinkInfo(name)
+		c.read[name] = time.Now()
+	}
+	return c.infos[name]
+}
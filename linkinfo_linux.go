@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ssidTimeout bounds how long the wireless tools may take to name the
+// network, since they run on the UI goroutine
+const ssidTimeout = 300 * time.Millisecond
+
+// readLinkInfo reads an interface's speed from sysfs, and for wireless
+// interfaces its signal level from /proc/net/wireless and its network from
+// iwgetid or iw. Virtual interfaces such as veth report a nominal speed,
+// so it is left out for them.
+func readLinkInfo(name string) linkInfo {
+	var info linkInfo
+	dir := "/sys/class/net/" + name
+	if _, err := os.Stat(dir + "/wireless"); err == nil {
+		info.Wireless = true
+		info.SignalDBm = wirelessSignal(name)
+		info.SSID = wirelessSSID(name)
+	}
+	if target, err := os.Readlink(dir); err == nil && strings.Contains(target, "/virtual/") {
+		return info
+	}
+	// Reading speed fails for links that are down, and it is -1 when the
+	// driver doesn't know
+	if data, err := os.ReadFile(dir + "/speed"); err == nil {
+		if speed, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && speed > 0 {
+			info.SpeedMbps = speed
+		}
+	}
+	return info
+}
+
+// wirelessSignal returns an interface's signal level in dBm from
+// /proc/net/wireless, or 0 when it isn't listed
+func wirelessSignal(name string) int {
+	f, err := os.Open("/proc/net/wireless")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// e.g. " wlan0: 0000   54.  -56.  -256        0 ..."
+		iface, rest, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || iface != name {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 3 {
+			return 0
+		}
+		level, err := strconv.Atoi(strings.TrimSuffix(fields[2], "."))
+		if err != nil {
+			return 0
+		}
+		// Some drivers report the level as an unsigned byte
+		if level > 63 {
+			level -= 256
+		}
+		if level >= 0 {
+			return 0
+		}
+		return level
+	}
+	return 0
+}
+
+// wirelessSSID returns the name of the network an interface is joined to,
+// or "" when neither iwgetid nor iw is installed or it isn't joined to one
+func wirelessSSID(name string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), ssidTimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, "iwgetid", "-r", name).Output(); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	out, err := exec.CommandContext(ctx, "iw", "dev", name, "link").Output()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if ssid, ok := strings.CutPrefix(strings.TrimSpace(line), "SSID: "); ok {
+			return ssid
+		}
+	}
+	return ""
+}
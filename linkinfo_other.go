@@ -0,0 +1,9 @@
+//go:build !linux
+
+package main
+
+// readLinkInfo returns no link details, since only Linux exposes them
+// without elevated tools
+func readLinkInfo(name string) linkInfo {
+	return linkInfo{}
+}
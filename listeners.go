@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// listenerInterval is how often the listening ports panel is refreshed.
+// Listening sockets rarely change, and finding their owners is as slow as
+// listing every connection.
+const listenerInterval = 15 * time.Second
+
+// listenerAddrWidth is the width of the panel's address column, enough for
+// most IPv6 addresses
+const listenerAddrWidth = 26
+
+// listener is a listening socket, or several sharing a protocol, address,
+// and port, such as those of a server's worker processes
+type listener struct {
+	Proto     string   // "tcp", "tcp6", "udp", or "udp6"
+	Addr      string   // Local address, e.g. "0.0.0.0" or "::1"
+	Port      uint32   // Local port
+	Processes []string // Names of the owning processes, sorted, or empty when unknown
+}
+
+// listenerSnapshot is the result of one pass of the listener collector
+type listenerSnapshot struct {
+	Listeners []listener // Ordered by port, then protocol and address
+	Limited   bool       // Owners of other users' sockets couldn't be found
+	Err       error
+}
+
+// ListenerCollector lists listening sockets on its own goroutine every
+// listenerInterval, publishing the results on Snapshots
+type ListenerCollector struct {
+	Snapshots chan listenerSnapshot // Latest listening sockets
+	done      chan struct{}         // Closed by Stop to end the goroutine
+	wg        sync.WaitGroup        // Tracks the running goroutine
+}
+
+func NewListenerCollector() *ListenerCollector {
+	return &ListenerCollector{
+		Snapshots: make(chan listenerSnapshot, 1),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start launches the collector goroutine
+func (c *ListenerCollector) Start() {
+	c.wg.Add(1)
+	go c.run()
+}
+
+// Stop ends the collector goroutine and waits for it to exit
+func (c *ListenerCollector) Stop() {
+	close(c.done)
+	c.wg.Wait()
+}
+
+func (c *ListenerCollector) run() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(listenerInterval)
+	defer ticker.Stop()
+	for {
+		snap := collectListeners()
+
+		// Replace any snapshot the UI has not picked up yet
+		select {
+		case <-c.Snapshots:
+		default:
+		}
+		select {
+		case c.Snapshots <- snap:
+		case <-c.done:
+			return
+		}
+
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectListeners lists listening TCP sockets and unconnected UDP ones,
+// combining sockets that share a protocol, address, and port
+func collectListeners() listenerSnapshot {
+	conns, err := net.Connections("inet")
+	if err != nil {
+		return listenerSnapshot{Err: err}
+	}
+
+	names := make(map[int32]string)
+	type key struct {
+		proto, addr string
+		port        uint32
+	}
+	byKey := make(map[key]map[string]bool)
+	for _, conn := range conns {
+		proto := "tcp"
+		if conn.Type == syscall.SOCK_DGRAM {
+			proto = "udp"
+		}
+		if proto == "tcp" && conn.Status != "LISTEN" || proto == "udp" && conn.Raddr.Port != 0 {
+			continue
+		}
+		if conn.Family == syscall.AF_INET6 {
+			proto += "6"
+		}
+		k := key{proto, conn.Laddr.IP, conn.Laddr.Port}
+		owners, ok := byKey[k]
+		if !ok {
+			owners = make(map[string]bool)
+			byKey[k] = owners
+		}
+		if conn.Pid == 0 {
+			continue
+		}
+		name, ok := names[conn.Pid]
+		if !ok {
+			name = fmt.Sprintf("pid %d", conn.Pid)
+			if p, err := process.NewProcess(conn.Pid); err == nil {
+				if n, err := p.Name(); err == nil && n != "" {
+					name = n
+				}
+			}
+			names[conn.Pid] = name
+		}
+		owners[name] = true
+	}
+
+	snap := listenerSnapshot{Limited: listenersLimited()}
+	for k, owners := range byKey {
+		l := listener{Proto: k.proto, Addr: k.addr, Port: k.port}
+		for name := range owners {
+			l.Processes = append(l.Processes, name)
+		}
+		sort.Strings(l.Processes)
+		snap.Listeners = append(snap.Listeners, l)
+	}
+	sort.Slice(snap.Listeners, func(i, j int) bool {
+		a, b := snap.Listeners[i], snap.Listeners[j]
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		if a.Proto != b.Proto {
+			return a.Proto < b.Proto
+		}
+		return a.Addr < b.Addr
+	})
+	return snap
+}
+
+// listenersLimited reports whether other users' listening sockets are
+// missing their owners. On Linux every socket is listed, but finding its
+// process means reading the owner's file descriptors, which needs root.
+func listenersLimited() bool {
+	if runtime.GOOS == "linux" {
+		return os.Geteuid() != 0
+	}
+	return socketsLimited()
+}
+
+// ListenerPanel lists the listening TCP and UDP sockets with their owning
+// processes. It is drawn over the disk section while open, and only
+// collects while open.
+type ListenerPanel struct {
+	*widgets.Paragraph
+	Active    bool
+	collector *ListenerCollector
+}
+
+func createListenerPanel() *ListenerPanel {
+	p := &ListenerPanel{Paragraph: widgets.NewParagraph()}
+	p.Border = true
+	p.WrapText = false
+	p.TitleStyle.Fg = theme.Title
+	p.BorderStyle.Fg = theme.Panel
+	return p
+}
+
+// Open shows the panel and starts collecting
+func (p *ListenerPanel) Open() {
+	if p.Active {
+		return
+	}
+	p.Active = true
+	p.Title = "Listening Ports (L to close)"
+	p.Text = "[Collecting...](fg:warn)"
+	p.collector = NewListenerCollector()
+	p.collector.Start()
+}
+
+// Close hides the panel and stops collecting
+func (p *ListenerPanel) Close() {
+	if !p.Active {
+		return
+	}
+	p.Active = false
+	p.collector.Stop()
+	p.collector = nil
+}
+
+// Snapshots returns the channel the collector publishes on, or nil while
+// the panel is closed so that receiving from it blocks
+func (p *ListenerPanel) Snapshots() <-chan listenerSnapshot {
+	if p.collector == nil {
+		return nil
+	}
+	return p.collector.Snapshots
+}
+
+// Update shows a new snapshot
+func (p *ListenerPanel) Update(snap listenerSnapshot) {
+	p.Title = fmt.Sprintf("Listening Ports: %d (L to close)", len(snap.Listeners))
+	if snap.Err != nil {
+		p.Title = "Listening Ports (L to close)"
+		p.Text = fmt.Sprintf("[Error listing sockets: %v](fg:crit)", snap.Err)
+		return
+	}
+
+	rows := p.Inner.Dy() - 1
+	if snap.Limited {
+		rows--
+	}
+	lines := []string{fmt.Sprintf("[%-5s %-*s %5s  %s](fg:label)", "Proto", listenerAddrWidth, "Address", "Port", "Process")}
+	for i, l := range snap.Listeners {
+		if i == rows-1 && len(snap.Listeners) > rows {
+			lines = append(lines, fmt.Sprintf("[... %d more](fg:text)", len(snap.Listeners)-i))
+			break
+		}
+		owner := "?"
+		if len(l.Processes) > 0 {
+			owner = strings.Join(l.Processes, ", ")
+		}
+		lines = append(lines, fmt.Sprintf("%-5s %-*s %5d  %s",
+			l.Proto, listenerAddrWidth, truncateToWidth(l.Addr, listenerAddrWidth), l.Port, owner))
+	}
+	if len(snap.Listeners) == 0 {
+		lines = append(lines, "No listening sockets")
+	}
+	if snap.Limited {
+		lines = append(lines, "[needs root for all processes](fg:warn)")
+	}
+	p.Text = strings.Join(lines, "\n")
+}
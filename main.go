@@ -1,20 +1,33 @@
 package main
 
 import (
+	"cmp"
+	"errors"
 	"flag"
 	"fmt"
+	"image"
 	"log"
+	"math"
 	"os"
+	"os/user"
+	"regexp"
+	"runtime"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
+	"github.com/mattn/go-runewidth"
+	termbox "github.com/nsf/termbox-go"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/mem"
-	"github.com/shirou/gopsutil/v3/net"
 	"github.com/shirou/gopsutil/v3/process"
 )
 
@@ -22,566 +35,3508 @@ const version = "0.1.1"
 
 // ProcessInfo represents a process with its resource usage
 type ProcessInfo struct {
-	PID     int32
-	Name    string
-	CPU     float64
-	Memory  float64
-	Command string
+	PID           int32
+	PPID          int32 // Parent PID, or 0 when unknown
+	User          string
+	UID           int32 // Owning real UID, or -1 when unknown
+	Name          string
+	CPU           float64
+	Memory        float64 // Resident memory as a percentage of physical memory
+	RSS           uint64  // Resident set size in bytes
+	Shared        int64   // Resident bytes shared with other processes, or -1 when unavailable or not gathered
+	Swap          int64   // Bytes swapped out, or -1 when unavailable or not gathered
+	Command       string
+	Status        string    // Primary process state, e.g. process.Running or process.Zombie
+	Threads       int32     // Thread count, or -1 when it could not be read
+	Nice          int32     // Nice value (priority class on Windows), or niceUnknown
+	Container     string    // Short ID of the container the process runs in, or ""
+	CtxSwitchRate float64   // Voluntary+involuntary context switches/sec, or -1 when unavailable
+	OOMScore      int32     // Kernel OOM killer score, or -1 when unavailable or not gathered
+	FDs           int32     // Open file descriptors, or -1 when unreadable or not gathered
+	FDsNear       bool      // FDs is close to the soft RLIMIT_NOFILE limit
+	ReadBPS       float64   // Disk read rate in bytes/sec, or -1 when unavailable
+	WriteBPS      float64   // Disk write rate in bytes/sec, or -1 when unavailable
+	CPUTime       float64   // Cumulative user+system CPU time in seconds
+	Created       time.Time // Process start time, or zero when unknown
+	Count         int       // Number of processes aggregated into this row when grouped by name
+	Pinned        bool      // Always listed first, regardless of sort order and filter
+	Exited        bool      // Pinned process that is no longer running
+}
+
+// SortKey identifies the column the process list is ordered by
+type SortKey int
+
+const (
+	SortByCPU SortKey = iota
+	SortByMemory
+	SortByPID
+	SortByName
+	SortByThreads
+	SortByRead
+	SortByWrite
+	SortByTime
+	SortByAge
+	SortByFDs
+	SortByOOM
+)
+
+// CommandMode selects how the Command column renders a process's command
+type CommandMode int
+
+const (
+	CommandFull     CommandMode = iota // Full command line as reported
+	CommandBasename                    // Executable basename followed by the arguments
+	CommandName                        // Process name only
+)
+
+// String describes the mode for status messages
+func (m CommandMode) String() string {
+	switch m {
+	case CommandBasename:
+		return "executable name and arguments"
+	case CommandName:
+		return "process name only"
+	default:
+		return "full command line"
+	}
 }
 
 // ProcessList widget for displaying top processes
 type ProcessList struct {
 	*widgets.Table
-	Processes []ProcessInfo
+	Processes     []ProcessInfo            // Processes shown in the table, after filtering
+	Filter        string                   // Substring matched against Name and Command; a leading "!" hides matches instead
+	Pattern       *regexp.Regexp           // Startup filter from --filter, applied beneath Filter
+	PatternInvert bool                     // Hide processes matching Pattern instead of showing them
+	CaseSensitive bool                     // Match Filter case-sensitively
+	Grouped       bool                     // Collapse processes with the same Name into one row
+	ByUser        bool                     // Show one row per user instead of per process
+	Containers    bool                     // List only processes running in containers
+	Frozen        bool                     // Keep the row order fixed, updating values in place
+	CommandMode   CommandMode              // How the Command column is rendered
+	OwnerOnly     bool                     // List only processes owned by OwnerUID
+	OwnerUID      int32                    // UID matched by OwnerOnly
+	OwnerName     string                   // Name of OwnerUID, shown in the title
+	ShowKernel    bool                     // Include kernel threads, which are hidden by default
+	Columns       []*processColumn         // Columns shown, in display order
+	SortKey       SortKey                  // Column the list is ordered by
+	SortAscending bool                     // Sort direction for SortKey
+	SelectedPID   int32                    // PID of the highlighted row, tracked across re-sorts
+	selected      int                      // Index of the highlighted row in Processes
+	offset        int                      // Index of the first process shown below the header
+	collected     []ProcessInfo            // Every process from the last collection pass
+	matches       int                      // Number of collected processes matching Filter
+	zombies       int                      // Number of zombie processes in the last collection pass
+	partial       int                      // Processes in the last pass with fields that could not be read
+	pins          map[int32]*pinnedProcess // Pinned PIDs with their last known details
+	cpuHistory    []float64                // Recent CPU% samples of the selected process
+	historyPID    int32                    // PID cpuHistory was sampled from
+	processView   savedView                // Per-process sort and selection to restore when leaving the user view
+	MemoryView    bool                     // Showing the memory-focused column preset
+	beforeMemory  savedColumns             // Columns and sort to restore when leaving the memory view
+	navigated     time.Time                // When the selection was last moved
+	revealed      map[int32]bool           // PIDs shown despite the filters after jumping to them
+	childrenOf    int32                    // Parent whose children C is cycling through
+}
+
+// navigationFreeze is how long the row order stays fixed after the
+// selection moves, so rows don't reorder under the cursor
+const navigationFreeze = 3 * time.Second
+
+// wheelRows is how far one notch of the mouse wheel moves the selection
+const wheelRows = 3
+
+// savedView holds the sort and selection of the per-process view while the
+// per-user view is shown
+type savedView struct {
+	sortKey     SortKey
+	ascending   bool
+	selectedPID int32
+}
+
+// savedColumns holds the column set and sort of the process table while the
+// memory view is shown
+type savedColumns struct {
+	columns   []*processColumn
+	sortKey   SortKey
+	ascending bool
+}
+
+// cpuHistoryLength caps how many CPU% samples are kept for the selected process
+const cpuHistoryLength = 60
+
+// pinnedProcess remembers a pinned process so it can still be shown for a
+// few snapshots after it exits
+type pinnedProcess struct {
+	info   ProcessInfo // Last known details
+	exited int         // Snapshots since the process was last seen
 }
 
-func createProcessList(x, y, width, height int) *ProcessList {
+// pinExitedSnapshots is how many snapshots an exited pinned process stays
+// listed before its pin is dropped
+const pinExitedSnapshots = 5
+
+func createProcessList(x, y, width, height int, columns []*processColumn) *ProcessList {
 	pl := &ProcessList{
-		Table: widgets.NewTable(),
+		Table:    widgets.NewTable(),
+		Columns:  columns,
+		pins:     make(map[int32]*pinnedProcess),
+		revealed: make(map[int32]bool),
 	}
 	pl.Title = "Top Processes"
 	pl.Border = true
 	pl.SetRect(x, y, width, height)
 	pl.Rows = [][]string{
-		{"Name", "CPU%", "Mem%", "Command"},
+		pl.headerRow(),
 	}
-	pl.TextStyle = ui.NewStyle(ui.ColorWhite)
-	pl.updateColumnWidths(width)
+	pl.TextStyle = ui.NewStyle(theme.Text)
+	pl.FillRow = true // Highlight the full width of the selected row
+	pl.updateColumnWidths()
 	return pl
 }
 
-func (pl *ProcessList) updateColumnWidths(width int) {
-	pl.ColumnWidths = []int{
-		int(float64(width) * 0.2), // Name: 20% of width
-		int(float64(width) * 0.1), // CPU%: 10% of width
-		int(float64(width) * 0.1), // Mem%: 10% of width
-		int(float64(width) * 0.6), // Command: 60% of width
+// Widths of the fixed-width columns; the other columns share what is left
+const (
+	pidColumnWidth       = 7
+	userColumnWidth      = 10
+	stateColumnWidth     = 1
+	threadsColumnWidth   = 5
+	ioColumnWidth        = 11
+	timeColumnWidth      = 9
+	ageColumnWidth       = 6
+	niceColumnWidth      = 3
+	containerColumnWidth = containerIDLength
+	rssColumnWidth       = 9
+	ctxColumnWidth       = 8
+	oomColumnWidth       = 4
+	fdsColumnWidth       = 6
+)
+
+// updateColumnWidths sizes the active columns to the table's inner width.
+// Fixed-width columns get their width, the others split the rest by weight,
+// and a Fill column (Command) absorbs whatever is left over.
+func (pl *ProcessList) updateColumnWidths() {
+	// Columns are separated by a single space
+	columns := pl.visibleColumns()
+	rest := pl.Inner.Dx() - (len(columns) - 1)
+	weights := 0.0
+	for _, col := range columns {
+		rest -= col.Width
+		weights += col.Weight
+	}
+	if rest < 0 {
+		rest = 0
+	}
+
+	pl.ColumnWidths = make([]int, len(columns))
+	fill, left := -1, rest
+	for i, col := range columns {
+		switch {
+		case col.Width > 0:
+			pl.ColumnWidths[i] = col.Width
+		case col.Fill:
+			fill = i
+		default:
+			pl.ColumnWidths[i] = int(float64(rest) * col.Weight / weights)
+			left -= pl.ColumnWidths[i]
+		}
+	}
+	if fill >= 0 {
+		pl.ColumnWidths[fill] = left
 	}
 }
 
-func (pl *ProcessList) collectProcessInfo() error {
-	processes, err := process.Processes()
-	if err != nil {
-		return err
+// visibleColumns returns the columns currently drawn, which are fixed while
+// the per-user view is shown
+func (pl *ProcessList) visibleColumns() []*processColumn {
+	if pl.ByUser {
+		return userViewColumns
 	}
+	return pl.Columns
+}
 
-	pl.Processes = make([]ProcessInfo, 0)
-	for _, p := range processes {
-		info, err := pl.getProcessInfo(p)
-		if err != nil {
-			continue
+// HasColumn reports whether the column called name is shown
+func (pl *ProcessList) HasColumn(name string) bool {
+	for _, col := range pl.Columns {
+		if col.Name == name {
+			return true
 		}
-		pl.Processes = append(pl.Processes, info)
 	}
-	return nil
+	return false
 }
 
-func (pl *ProcessList) getProcessInfo(p *process.Process) (ProcessInfo, error) {
-	name, err := p.Name()
-	if err != nil {
-		return ProcessInfo{}, err
+// ShowIO reports whether either disk I/O rate column is shown, and so
+// whether the collector needs to gather rates
+func (pl *ProcessList) ShowIO() bool {
+	return pl.HasColumn("read") || pl.HasColumn("write")
+}
+
+// setColumn shows or hides the column called name. A column being shown is
+// placed where it sits in processColumns relative to the ones already shown.
+func (pl *ProcessList) setColumn(name string, show bool) {
+	if pl.HasColumn(name) == show {
+		return
+	}
+	if !show {
+		for i, col := range pl.Columns {
+			if col.Name == name {
+				pl.Columns = append(pl.Columns[:i:i], pl.Columns[i+1:]...)
+				break
+			}
+		}
+		return
 	}
 
-	cpu, err := p.CPUPercent()
-	if err != nil {
-		return ProcessInfo{}, err
+	rank := make(map[string]int, len(processColumns))
+	for i, col := range processColumns {
+		rank[col.Name] = i
+	}
+	at := len(pl.Columns)
+	for i, col := range pl.Columns {
+		if rank[col.Name] > rank[name] {
+			at = i
+			break
+		}
 	}
+	pl.Columns = append(pl.Columns[:at:at], append([]*processColumn{lookupColumn(name)}, pl.Columns[at:]...)...)
+}
 
-	mem, err := p.MemoryPercent()
-	if err != nil {
-		return ProcessInfo{}, err
+// columnsChanged re-sorts and redraws after the column set changes. I/O
+// rates, descriptor counts, and OOM scores stop being gathered once their
+// columns are hidden, so sorting by them falls back to CPU.
+func (pl *ProcessList) columnsChanged() {
+	if !pl.ShowIO() && (pl.SortKey == SortByRead || pl.SortKey == SortByWrite) ||
+		!pl.HasColumn("fds") && pl.SortKey == SortByFDs ||
+		!pl.HasColumn("oom") && pl.SortKey == SortByOOM {
+		pl.SortKey, pl.SortAscending = SortByCPU, false
 	}
+	pl.sortProcesses()
+	pl.refreshRows()
+}
 
-	cmd, err := p.Cmdline()
-	if err != nil {
-		cmd = name
+// ToggleMemoryUnit switches the memory column between a percentage of
+// physical memory and absolute RSS. The sort order is unaffected since both
+// sort by bytes.
+func (pl *ProcessList) ToggleMemoryUnit() {
+	for i, col := range pl.Columns {
+		switch col.Name {
+		case "mem":
+			pl.Columns[i] = lookupColumn("rss")
+		case "rss":
+			pl.Columns[i] = lookupColumn("mem")
+		default:
+			continue
+		}
+		pl.refreshRows()
+		return
 	}
+}
 
-	return ProcessInfo{
-		PID:     p.Pid,
-		Name:    name,
-		CPU:     cpu,
-		Memory:  float64(mem),
-		Command: cmd,
-	}, nil
+// ToggleColumn shows or hides the column called name
+func (pl *ProcessList) ToggleColumn(name string) {
+	pl.setColumn(name, !pl.HasColumn(name))
+	pl.columnsChanged()
 }
 
-func (pl *ProcessList) sortProcesses() {
-	sort.Slice(pl.Processes, func(i, j int) bool {
-		return pl.Processes[i].CPU > pl.Processes[j].CPU
-	})
+// ToggleKernel shows or hides kernel threads. Hidden threads are skipped
+// during collection, so the change shows up with the next snapshot.
+func (pl *ProcessList) ToggleKernel() {
+	pl.ShowKernel = !pl.ShowKernel
 }
 
-func (pl *ProcessList) formatCommand(cmd string, width int) string {
-	if len(cmd) > width {
-		return cmd[:width-3] + "..."
+// collectOptions returns the settings the collector needs for the next pass
+func (pl *ProcessList) collectOptions() CollectOptions {
+	opts := CollectOptions{
+		ShowKernel:      pl.ShowKernel,
+		ShowIO:          pl.ShowIO(),
+		ShowFDs:         pl.HasColumn("fds"),
+		ShowMemory:      pl.HasColumn("shared") || pl.HasColumn("swap"),
+		ShowOOM:         pl.HasColumn("oom"),
+		ShowNice:        pl.HasColumn("ni"),
+		ShowCtxSwitches: pl.HasColumn("ctxsw"),
+		// The container filter needs container IDs even when the column is hidden
+		ShowContainer: pl.HasColumn("container") || pl.Containers,
+	}
+	if !pl.canLimitCollection() {
+		return opts
+	}
+
+	// Only the rows up to the bottom of the screen, plus a margin for
+	// processes climbing the list, need every field
+	opts.Limit = pl.offset + pl.visibleRows() + len(pl.pins) + collectMargin
+	opts.SortKey, opts.SortAscending = pl.SortKey, pl.SortAscending
+	opts.Keep = make(map[int32]bool)
+	for pid := range pl.pins {
+		opts.Keep[pid] = true
+	}
+	for pid := range pl.revealed {
+		opts.Keep[pid] = true
+	}
+	// Rows on screen may sit below the limit, e.g. while the order is frozen
+	end := min(pl.offset+pl.visibleRows(), len(pl.Processes))
+	for _, p := range pl.Processes[pl.offset:end] {
+		opts.Keep[p.PID] = true
 	}
-	return cmd
+	opts.Keep[pl.SelectedPID] = true
+	return opts
 }
 
-func (pl *ProcessList) update() {
-	// Update column widths based on current width
-	pl.updateColumnWidths(pl.Block.Rectangle.Dx())
+// collectMargin is how many processes past the bottom of the screen still
+// get every field, so rows moving into view are already complete
+const collectMargin = 10
 
-	// Collect and sort process information
-	if err := pl.collectProcessInfo(); err != nil {
-		pl.Rows = [][]string{{"Error getting processes"}}
-		return
+// canLimitCollection reports whether the collector can pick the rows to
+// show by itself. Filters and grouping decide rows based on every process,
+// and the collector only sorts by keys that don't need the expensive fields.
+func (pl *ProcessList) canLimitCollection() bool {
+	if pl.Filter != "" || pl.Pattern != nil || pl.Containers || pl.OwnerOnly || pl.Grouped || pl.ByUser {
+		return false
 	}
-	pl.sortProcesses()
-
-	// Update table rows
-	rows := make([][]string, 0)
-	rows = append(rows, []string{"Name", "CPU%", "Mem%", "Command"})
+	switch pl.SortKey {
+	case SortByCPU, SortByMemory, SortByPID, SortByName, SortByAge:
+		return true
+	}
+	return false
+}
 
-	// Calculate available width for command column
-	availableWidth := pl.Block.Rectangle.Dx() - 2
-	commandWidth := int(float64(availableWidth) * 0.6)
+// ToggleIO shows or hides the disk I/O rate columns. Rates are only gathered
+// while the columns are visible, so they fill in from the next snapshot.
+func (pl *ProcessList) ToggleIO() {
+	show := !pl.ShowIO()
+	pl.setColumn("read", show)
+	pl.setColumn("write", show)
+	pl.columnsChanged()
+}
 
-	// Add process rows
-	for _, p := range pl.Processes {
-		rows = append(rows, []string{
-			p.Name,
-			fmt.Sprintf("%.1f", p.CPU),
-			fmt.Sprintf("%.1f", p.Memory),
-			pl.formatCommand(p.Command, commandWidth),
-		})
+// applyFilter rebuilds Processes from the collected set, keeping only the
+// processes whose name or command contains Filter (or, for "!text", does
+// not) and grouping them by name when Grouped is set
+func (pl *ProcessList) applyFilter() {
+	pl.Processes = make([]ProcessInfo, 0, len(pl.collected))
+	filter, invert := strings.CutPrefix(pl.Filter, "!")
+	if !pl.CaseSensitive {
+		filter = strings.ToLower(filter)
+	}
+	for _, p := range pl.collected {
+		// Pinned processes are added back below, except in the user view
+		// where they count towards their user like any other
+		if _, pinned := pl.pins[p.PID]; pinned && !pl.ByUser {
+			continue
+		}
+		if pl.revealed[p.PID] {
+			pl.Processes = append(pl.Processes, p)
+			continue
+		}
+		if pl.Pattern != nil && (pl.Pattern.MatchString(p.Name) || pl.Pattern.MatchString(p.Command)) == pl.PatternInvert {
+			continue
+		}
+		if pl.Containers && p.Container == "" {
+			continue
+		}
+		// UIDs rather than names, since name lookups can be slow or fail
+		if pl.OwnerOnly && p.UID != pl.OwnerUID {
+			continue
+		}
+		if filter == "" || pl.filterMatches(p, filter) != invert {
+			pl.Processes = append(pl.Processes, p)
+		}
+	}
+	pl.matches = len(pl.Processes)
+	if pl.ByUser {
+		pl.Processes = groupByUser(pl.Processes)
+		pl.updateTitle()
+		return
+	}
+	if pl.Grouped {
+		pl.Processes = groupByName(pl.Processes)
 	}
 
-	pl.Rows = rows
+	// Pinned processes bypass the filter and grouping
+	for _, pin := range pl.pins {
+		pl.Processes = append(pl.Processes, pin.info)
+	}
+	pl.updateTitle()
 }
 
-// CPUGauge tracks a CPU gauge with its previous value and target value for smooth transitions
-type CPUGauge struct {
-	*widgets.Gauge
-	CurrentPercent float64 // Current displayed value (for smooth transitions)
-	TargetPercent  float64 // Target value to animate towards
+// filterMatches reports whether p's name or command contains filter, which
+// is already lower case unless CaseSensitive is set
+func (pl *ProcessList) filterMatches(p ProcessInfo, filter string) bool {
+	name, command := p.Name, p.Command
+	if !pl.CaseSensitive {
+		name, command = strings.ToLower(name), strings.ToLower(command)
+	}
+	return strings.Contains(name, filter) || strings.Contains(command, filter)
 }
 
-// NetworkData stores network traffic data for graphing
-type NetworkData struct {
-	RxData   []float64 // History of received data rates
-	TxData   []float64 // History of transmitted data rates
-	MaxValue float64   // Maximum value for scaling
+// TogglePin pins or unpins the selected process
+func (pl *ProcessList) TogglePin() {
+	info, ok := pl.Selected()
+	if !ok || pl.ByUser {
+		return
+	}
+	if _, pinned := pl.pins[info.PID]; pinned {
+		delete(pl.pins, info.PID)
+	} else {
+		info.Pinned = true
+		pl.pins[info.PID] = &pinnedProcess{info: info}
+	}
+	pl.applyFilter()
+	pl.sortProcesses()
+	pl.refreshRows()
 }
 
-// DiskData stores disk I/O data for graphing
-type DiskData struct {
-	ReadData  []float64 // History of read speeds
-	WriteData []float64 // History of write speeds
-	MaxValue  float64   // Maximum value for scaling
+// updatePins refreshes pinned processes from the latest collection pass.
+// Pins whose process has exited are kept, marked as exited, for
+// pinExitedSnapshots snapshots so the exit is noticeable.
+func (pl *ProcessList) updatePins() {
+	running := make(map[int32]ProcessInfo, len(pl.pins))
+	for _, p := range pl.collected {
+		if _, pinned := pl.pins[p.PID]; pinned {
+			running[p.PID] = p
+		}
+	}
+	for pid, pin := range pl.pins {
+		if info, ok := running[pid]; ok {
+			info.Pinned = true
+			pin.info, pin.exited = info, 0
+			continue
+		}
+		pin.exited++
+		pin.info.Exited = true
+		if pin.exited > pinExitedSnapshots {
+			delete(pl.pins, pid)
+		}
+	}
 }
 
-func main() {
-	showVersion := flag.Bool("version", false, "Show version information")
-	flag.Parse()
+// groupByName collapses processes sharing a Name into one row with summed
+// CPU and memory. The PID, user, and command come from the member using the
+// most CPU.
+func groupByName(processes []ProcessInfo) []ProcessInfo {
+	return groupProcesses(processes, func(p ProcessInfo) string { return p.Name })
+}
 
-	if *showVersion {
-		fmt.Printf("SysGoMon version %s\n", version)
-		os.Exit(0)
+// groupByUser collapses each user's processes into one row, named after the
+// user, with summed CPU and memory
+func groupByUser(processes []ProcessInfo) []ProcessInfo {
+	groups := groupProcesses(processes, func(p ProcessInfo) string { return p.User })
+	for i := range groups {
+		groups[i].Name = groups[i].User
 	}
+	return groups
+}
 
-	if err := ui.Init(); err != nil {
-		log.Fatalf("Failed to initialize termui: %v", err)
+// groupProcesses collapses processes with the same key into one row with
+// summed usage, taking the PID, user, and command of the member using the
+// most CPU
+func groupProcesses(processes []ProcessInfo, key func(ProcessInfo) string) []ProcessInfo {
+	groups := make([]ProcessInfo, 0)
+	heaviest := make([]ProcessInfo, 0)
+	index := make(map[string]int)
+	for _, p := range processes {
+		k := key(p)
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(groups)
+			heaviest = append(heaviest, p)
+			p.Count = 1
+			groups = append(groups, p)
+			continue
+		}
+		g := &groups[i]
+		if h := heaviest[i]; p.CPU > h.CPU || (p.CPU == h.CPU && p.Memory > h.Memory) {
+			heaviest[i] = p
+			g.PID, g.User, g.Command, g.Status, g.Nice = p.PID, p.User, p.Command, p.Status, p.Nice
+			g.Container = p.Container
+		}
+		g.CPU += p.CPU
+		g.Memory += p.Memory
+		g.RSS += p.RSS
+		if p.Shared > 0 && g.Shared >= 0 {
+			g.Shared += p.Shared
+		}
+		if p.Swap > 0 && g.Swap >= 0 {
+			g.Swap += p.Swap
+		}
+		if p.Threads > 0 && g.Threads >= 0 {
+			g.Threads += p.Threads
+		}
+		if p.FDs > 0 && g.FDs >= 0 {
+			g.FDs += p.FDs
+		}
+		g.FDsNear = g.FDsNear || p.FDsNear
+		// The group is as likely to be OOM killed as its likeliest member
+		if p.OOMScore > g.OOMScore {
+			g.OOMScore = p.OOMScore
+		}
+		g.CPUTime += p.CPUTime
+		if p.CtxSwitchRate > 0 && g.CtxSwitchRate >= 0 {
+			g.CtxSwitchRate += p.CtxSwitchRate
+		}
+		if p.ReadBPS > 0 && g.ReadBPS >= 0 {
+			g.ReadBPS += p.ReadBPS
+		}
+		if p.WriteBPS > 0 && g.WriteBPS >= 0 {
+			g.WriteBPS += p.WriteBPS
+		}
+		g.Count++
 	}
-	defer ui.Close()
-
-	// Set the animation speed (lower = slower transitions)
-	animationSpeed := 0.03 // How quickly to transition to target value
-
-	// Get the terminal dimensions
-	termWidth, termHeight := ui.TerminalDimensions()
+	return groups
+}
 
-	// Create header with system info
-	header := widgets.NewParagraph()
-	header.Title = "SysGoMon"
-	header.Border = true
-	header.SetRect(0, 0, termWidth, 3)
-	header.TextStyle.Fg = ui.ColorCyan
-	header.TitleStyle.Fg = ui.ColorWhite
+// ToggleGrouped switches between per-PID rows and rows grouped by name,
+// reusing the last collected processes
+func (pl *ProcessList) ToggleGrouped() {
+	pl.Grouped = !pl.Grouped
+	pl.applyFilter()
+	pl.sortProcesses()
+	pl.refreshRows()
+}
 
-	// Create CPU gauges
-	cpuTitle, cpuGauges, cpuHeight := createCPUGauges(termWidth)
+// ToggleContainers limits the list to containerized processes, or lifts the
+// limit. Container IDs are only gathered while needed, so the change shows up
+// with the next snapshot.
+func (pl *ProcessList) ToggleContainers() {
+	pl.Containers = !pl.Containers
+}
 
-	// Create Network stats and graph
-	netStats := widgets.NewParagraph()
-	netStats.Title = "Network Traffic"
-	netStats.Border = true
-	netStats.SetRect(0, cpuHeight, termWidth, cpuHeight+4)
-	netStats.TitleStyle.Fg = ui.ColorWhite
+// ToggleOwner limits the list to processes owned by OwnerUID, or lifts the
+// limit, reusing the last collected processes
+func (pl *ProcessList) ToggleOwner() {
+	pl.OwnerOnly = !pl.OwnerOnly
+	pl.applyFilter()
+	pl.sortProcesses()
+	pl.refreshRows()
+}
 
-	// Network graph for historical data
-	netGraph := widgets.NewPlot()
-	netGraph.Title = "Network Traffic History (Mbps)"
-	netGraph.Border = true
-	netGraph.LineColors[0] = ui.ColorGreen // RX
-	netGraph.LineColors[1] = ui.ColorBlue  // TX
-	netGraph.AxesColor = ui.ColorWhite
-	netGraph.DrawDirection = widgets.DrawRight // Draw from left to right
-	netGraph.SetRect(0, netStats.Block.Rectangle.Max.Y, termWidth, netStats.Block.Rectangle.Max.Y+9)
-	netGraph.TitleStyle.Fg = ui.ColorWhite
-	// Use the terminal width to determine how many data points to store
-	// This ensures we have enough points to span the entire width
-	dataPointCount := termWidth // Use full terminal width to ensure graph touches the right edge
-	if dataPointCount < 100 {
-		dataPointCount = 100 // Minimum size
+// ToggleUserView switches between per-process rows and one row per user,
+// sorted by CPU. The per-process sort and selection are put back when
+// leaving the user view.
+func (pl *ProcessList) ToggleUserView() {
+	if pl.ByUser {
+		pl.SortKey, pl.SortAscending = pl.processView.sortKey, pl.processView.ascending
+		pl.SelectedPID = pl.processView.selectedPID
+	} else {
+		pl.processView = savedView{pl.SortKey, pl.SortAscending, pl.SelectedPID}
+		pl.SortKey, pl.SortAscending = SortByCPU, false
 	}
-	netGraph.Data = make([][]float64, 2)
-	netGraph.Data[0] = make([]float64, dataPointCount) // RX data with terminal-width adjusted count
-	netGraph.Data[1] = make([]float64, dataPointCount) // TX data with terminal-width adjusted count
-	netGraph.PlotType = widgets.LineChart              // Use line chart for better visibility
-	netGraph.ShowAxes = false                          // Hide the axis numbers
-	netGraph.HorizontalScale = 1.0                     // Ensure it uses full width
-	// Set plot mode to stretch to fill the entire width
-	netGraph.AxesColor = ui.ColorClear // Make axes invisible
+	pl.ByUser = !pl.ByUser
+	pl.applyFilter()
+	pl.sortProcesses()
+	pl.refreshRows()
+}
 
-	// Network traffic history
-	netData := NetworkData{
-		RxData:   make([]float64, dataPointCount),
-		TxData:   make([]float64, dataPointCount),
-		MaxValue: 0.1, // Start with a small non-zero value
+// ToggleMemoryView switches the process table to the memory-focused preset
+// of memoryViewColumns sorted by resident memory, or back to exactly the
+// columns and sort it had before
+func (pl *ProcessList) ToggleMemoryView() {
+	if pl.MemoryView {
+		pl.Columns = pl.beforeMemory.columns
+		pl.SortKey, pl.SortAscending = pl.beforeMemory.sortKey, pl.beforeMemory.ascending
+	} else {
+		pl.beforeMemory = savedColumns{append([]*processColumn(nil), pl.Columns...), pl.SortKey, pl.SortAscending}
+		pl.Columns = nil
+		for _, name := range strings.Split(memoryViewColumns, ",") {
+			pl.Columns = append(pl.Columns, lookupColumn(name))
+		}
+		pl.SortKey, pl.SortAscending = SortByMemory, false
 	}
+	pl.MemoryView = !pl.MemoryView
+	pl.columnsChanged()
+}
 
-	// Create Disk I/O stats and graph
-	diskStats := widgets.NewParagraph()
-	diskStats.Title = "Disk I/O"
-	diskStats.Border = true
-	diskStats.SetRect(0, netGraph.Block.Rectangle.Max.Y, termWidth, netGraph.Block.Rectangle.Max.Y+4)
-	diskStats.TitleStyle.Fg = ui.ColorWhite
-
-	// Disk I/O graph for historical data
-	diskGraph := widgets.NewPlot()
-	diskGraph.Title = "Disk I/O History (MB/s)"
-	diskGraph.Border = true
-	diskGraph.LineColors[0] = ui.ColorGreen // Read
-	diskGraph.LineColors[1] = ui.ColorRed   // Write
-	diskGraph.AxesColor = ui.ColorWhite
-	diskGraph.DrawDirection = widgets.DrawRight // Draw from left to right
-	diskGraph.SetRect(0, diskStats.Block.Rectangle.Max.Y, termWidth, diskStats.Block.Rectangle.Max.Y+9)
-	diskGraph.TitleStyle.Fg = ui.ColorWhite
-	diskGraph.Data = make([][]float64, 2)
-	diskGraph.Data[0] = make([]float64, dataPointCount) // Read data
-	diskGraph.Data[1] = make([]float64, dataPointCount) // Write data
-	diskGraph.PlotType = widgets.LineChart              // Use line chart for better visibility
-	diskGraph.ShowAxes = false                          // Hide the axis numbers
-	diskGraph.HorizontalScale = 1.0                     // Ensure it uses full width
-	diskGraph.AxesColor = ui.ColorClear                 // Make axes invisible
+// SetFilter changes the filter and immediately re-applies it to the last
+// collected processes
+func (pl *ProcessList) SetFilter(filter string) {
+	pl.Filter = filter
+	pl.applyFilter()
+	pl.sortProcesses()
+	pl.refreshRows()
+}
 
-	// Disk I/O history
-	diskData := DiskData{
-		ReadData:  make([]float64, dataPointCount),
-		WriteData: make([]float64, dataPointCount),
-		MaxValue:  0.1, // Start with a small non-zero value
+func (pl *ProcessList) updateTitle() {
+	filtered := pl.Filter != "" || pl.Pattern != nil || pl.Containers || pl.OwnerOnly
+	tags := []string{fmt.Sprintf("%d total", len(pl.collected))}
+	if filtered {
+		tags[0] = fmt.Sprintf("showing %d of %d", pl.matches, len(pl.collected))
+	}
+	if pl.zombies == 1 {
+		tags = append(tags, "1 zombie")
+	} else if pl.zombies > 1 {
+		tags = append(tags, fmt.Sprintf("%d zombies", pl.zombies))
+	}
+	if pl.Pattern != nil {
+		pattern := strings.TrimPrefix(pl.Pattern.String(), "(?i)")
+		if pl.PatternInvert {
+			pattern = "not " + pattern
+		}
+		tags = append(tags, fmt.Sprintf("pattern: %s", pattern))
+	}
+	if pl.Filter != "" {
+		tags = append(tags, fmt.Sprintf("filter: %s", pl.Filter))
+	}
+	if pl.Containers {
+		tags = append(tags, "containers only")
+	}
+	if pl.OwnerOnly {
+		tags = append(tags, fmt.Sprintf("user: %s", pl.OwnerName))
+	}
+	if pl.frozen() {
+		tags = append(tags, "FROZEN")
+	}
+	if pl.MemoryView {
+		tags = append(tags, "memory view")
+	}
+	if pl.ByUser {
+		tags = append(tags, "per user")
+	} else if pl.Grouped {
+		tags = append(tags, "grouped by name")
+	}
+	if !pl.ShowKernel {
+		tags = append(tags, "kernel threads hidden")
 	}
 
-	// Create process list
-	processList := createProcessList(0, diskGraph.Block.Rectangle.Max.Y, termWidth, termHeight-1)
-	processList.TitleStyle.Fg = ui.ColorWhite
+	pl.Title = "Top Processes (" + strings.Join(tags, ", ") + ")"
+}
 
-	// Create footer with instructions
-	footer := widgets.NewParagraph()
-	footer.Border = false
-	footer.Text = "[Press q to quit](fg:red)"
-	footer.SetRect(0, termHeight-1, termWidth, termHeight)
+func (pl *ProcessList) sortProcesses() {
+	sort.Slice(pl.Processes, func(i, j int) bool {
+		a, b := pl.Processes[i], pl.Processes[j]
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		if c := compareProcesses(a, b, pl.SortKey); c != 0 {
+			if pl.SortAscending {
+				return c < 0
+			}
+			return c > 0
+		}
+		// Fall back to a unique key so equal keys keep a stable order between
+		// refreshes. Grouped and per-user rows take their PID from the busiest
+		// member, which changes from one snapshot to the next, so they are
+		// ordered by name instead.
+		if (pl.Grouped || pl.ByUser) && a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.PID < b.PID
+	})
+}
 
-	// Get initial network stats for baseline
-	netIOCounters, err := net.IOCounters(false)
-	if err != nil {
-		log.Printf("Error getting network stats: %v", err)
+func compareProcesses(a, b ProcessInfo, key SortKey) int {
+	switch key {
+	case SortByMemory:
+		// Compare bytes so the order is the same whichever unit is shown
+		return cmp.Compare(a.RSS, b.RSS)
+	case SortByPID:
+		return cmp.Compare(a.PID, b.PID)
+	case SortByName:
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	case SortByThreads:
+		return cmp.Compare(a.Threads, b.Threads)
+	case SortByRead:
+		return cmp.Compare(a.ReadBPS, b.ReadBPS)
+	case SortByWrite:
+		return cmp.Compare(a.WriteBPS, b.WriteBPS)
+	case SortByTime:
+		return cmp.Compare(a.CPUTime, b.CPUTime)
+	case SortByOOM:
+		return cmp.Compare(a.OOMScore, b.OOMScore)
+	case SortByFDs:
+		return cmp.Compare(a.FDs, b.FDs)
+	case SortByAge:
+		// Older processes have the larger age
+		return b.Created.Compare(a.Created)
+	default:
+		return cmp.Compare(a.CPU, b.CPU)
+	}
+}
+
+// SetSort orders the list by key. Selecting the active key again flips the
+// direction; a new key starts descending for usage columns and ascending for
+// PID, name, and age (so recently started processes come first).
+func (pl *ProcessList) SetSort(key SortKey) {
+	if pl.SortKey == key {
+		pl.SortAscending = !pl.SortAscending
+	} else {
+		pl.SortKey = key
+		pl.SortAscending = key == SortByPID || key == SortByName || key == SortByAge
 	}
-	prevNetIOStats := netIOCounters[0]
-	lastNetworkUpdate := time.Now()
+	pl.sortProcesses()
+	pl.refreshRows()
+}
 
-	// Get initial disk stats for baseline
-	diskIOCounters, err := disk.IOCounters()
-	if err != nil {
-		log.Printf("Error getting disk I/O stats: %v", err)
+// headerRow returns the column titles with the active sort column marked
+func (pl *ProcessList) headerRow() []string {
+	arrow := "▼"
+	if pl.SortAscending {
+		arrow = "▲"
 	}
-	prevDiskIOStats := make(map[string]disk.IOCountersStat)
-	for name, stat := range diskIOCounters {
-		prevDiskIOStats[name] = stat
+	columns := pl.visibleColumns()
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		title := col.Title
+		if col.Sortable && pl.SortKey == col.Sort {
+			title += arrow
+		}
+		header[i] = alignCell(col, title)
 	}
-	lastDiskUpdate := time.Now()
+	return header
+}
 
-	// Update system info in header
-	updateHeader(header)
+// alignCell pads text to the column width for right-aligned columns
+func alignCell(col *processColumn, text string) string {
+	if col.Right {
+		return fmt.Sprintf("%*s", col.Width, text)
+	}
+	return text
+}
 
-	// Initial render to set up the screen
-	ui.Clear()
-	ui.Render(header, cpuTitle)
-	for _, gauge := range cpuGauges {
-		ui.Render(gauge.Gauge)
+// displayName returns the name shown for p, marked with its group size,
+// whether it has exited, and whether it is pinned
+func displayName(p ProcessInfo) string {
+	name := p.Name
+	if p.Count > 0 {
+		name = fmt.Sprintf("%s (%d)", p.Name, p.Count)
+	}
+	if p.Exited {
+		name += " (exited)"
 	}
-	ui.Render(netStats, netGraph, diskStats, diskGraph, processList, footer)
+	if p.Pinned {
+		name = "*" + name
+	}
+	return name
+}
 
-	// Set up event handling
-	uiEvents := ui.PollEvents()
-	ticker := time.NewTicker(300 * time.Millisecond).C // Update every half second for more responsive display
+// CycleCommandMode switches the Command column to the next CommandMode
+func (pl *ProcessList) CycleCommandMode() {
+	pl.CommandMode = (pl.CommandMode + 1) % (CommandName + 1)
+	pl.refreshRows()
+}
 
-	// Main event loop
-	for {
-		select {
-		case e := <-uiEvents:
-			switch e.ID {
-			case "q", "<C-c>":
-				return
-			case "<Resize>":
-				payload := e.Payload.(ui.Resize)
-				termWidth, termHeight = payload.Width, payload.Height
+// commandText returns p's command as the Command column shows it in mode
+func commandText(p ProcessInfo, mode CommandMode) string {
+	switch mode {
+	case CommandName:
+		return p.Name
+	case CommandBasename:
+		exe, args, _ := strings.Cut(p.Command, " ")
+		if i := strings.LastIndexAny(exe, `/\`); i >= 0 && i < len(exe)-1 {
+			exe = exe[i+1:]
+		}
+		if args == "" {
+			return exe
+		}
+		return exe + " " + args
+	default:
+		return p.Command
+	}
+}
 
-				header.SetRect(0, 0, termWidth, 3)
+// formatCommand truncates cmd to fit in width terminal cells, ending with
+// "..." when there is room for it. Truncation works on runes and their
+// display width, so multi-byte and wide characters are never split.
+func formatCommand(cmd string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if displayWidth(cmd) <= width {
+		return cmd
+	}
+	if width < 3 {
+		return truncateToWidth(cmd, width)
+	}
+	return truncateToWidth(cmd, width-3) + "..."
+}
 
-				// Update CPU gauges position
-				cpuTitle, cpuGauges, cpuHeight = createCPUGauges(termWidth)
+// runeCells returns how many terminal cells r occupies. Combining marks
+// attach to the previous rune and take no space of their own.
+func runeCells(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me) {
+		return 0
+	}
+	return runewidth.RuneWidth(r)
+}
 
-				// Update network stats and graph positions
-				netStats.SetRect(0, cpuHeight, termWidth, cpuHeight+4)
-				netGraph.SetRect(0, netStats.Block.Rectangle.Max.Y, termWidth, netStats.Block.Rectangle.Max.Y+9)
+// displayWidth returns how many terminal cells s occupies
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeCells(r)
+	}
+	return width
+}
 
-				// Update data point count on resize to match new width
-				dataPointCount := termWidth // Use full terminal width
-				if dataPointCount < 100 {
-					dataPointCount = 100
-				}
-				// Only recreate data arrays if new width requires more points
-				if dataPointCount > len(netData.RxData) {
-					newRxData := make([]float64, dataPointCount)
-					newTxData := make([]float64, dataPointCount)
+// truncateToWidth returns the longest prefix of s that fits in width cells,
+// keeping any combining marks that follow the last rune
+func truncateToWidth(s string, width int) string {
+	used := 0
+	for i, r := range s {
+		cells := runeCells(r)
+		if used+cells > width {
+			return s[:i]
+		}
+		used += cells
+	}
+	return s
+}
 
-					// Copy existing data to preserve history
-					copy(newRxData[dataPointCount-len(netData.RxData):], netData.RxData)
-					copy(newTxData[dataPointCount-len(netData.TxData):], netData.TxData)
+// SetSnapshot swaps in the result of a collection pass and rebuilds the rows
+func (pl *ProcessList) SetSnapshot(snap processSnapshot) {
+	if snap.Err != nil {
+		pl.Rows = [][]string{{"Error getting processes"}}
+		return
+	}
+	pl.collected = snap.Processes
+	pl.partial = snap.Partial
+	pl.zombies = 0
+	for _, p := range pl.collected {
+		if p.Status == process.Zombie {
+			pl.zombies++
+		}
+	}
+	previous := pl.Processes
+	pl.updatePins()
+	pl.applyFilter()
+	pl.sortProcesses()
+	if pl.frozen() {
+		pl.keepOrder(previous)
+	}
+	pl.refreshRows()
+	pl.recordCPUHistory()
+}
+
+// frozen reports whether the row order is held, either by the Frozen toggle
+// or because the selection moved within the last navigationFreeze
+func (pl *ProcessList) frozen() bool {
+	return pl.Frozen || time.Since(pl.navigated) < navigationFreeze
+}
+
+// ToggleFreeze holds or releases the row order. Releasing it re-sorts
+// straight away.
+func (pl *ProcessList) ToggleFreeze() {
+	pl.Frozen = !pl.Frozen
+	if !pl.Frozen {
+		pl.navigated = time.Time{}
+		pl.sortProcesses()
+	}
+	pl.updateTitle()
+	pl.refreshRows()
+}
+
+// rowKey identifies a row across snapshots. Grouped and per-user rows take
+// their PID from the busiest member, which can change, so they are keyed by
+// name instead.
+type rowKey struct {
+	pid  int32
+	name string
+}
+
+func (pl *ProcessList) rowKey(p ProcessInfo) rowKey {
+	if (pl.Grouped || pl.ByUser) && !p.Pinned {
+		return rowKey{name: p.Name}
+	}
+	return rowKey{pid: p.PID}
+}
+
+// keepOrder reorders Processes to match the rows in previous, so a frozen
+// list only updates values in place. Rows that are new since previous keep
+// their sorted order after the existing ones.
+func (pl *ProcessList) keepOrder(previous []ProcessInfo) {
+	rank := make(map[rowKey]int, len(previous))
+	for i, p := range previous {
+		rank[pl.rowKey(p)] = i
+	}
+	sort.SliceStable(pl.Processes, func(i, j int) bool {
+		ri, iSeen := rank[pl.rowKey(pl.Processes[i])]
+		rj, jSeen := rank[pl.rowKey(pl.Processes[j])]
+		if iSeen != jSeen {
+			return iSeen
+		}
+		return iSeen && ri < rj
+	})
+}
+
+// PartialRows returns how many processes in the last collection pass had
+// fields that could not be read and are shown with placeholders
+func (pl *ProcessList) PartialRows() int {
+	return pl.partial
+}
+
+// recordCPUHistory appends the selected process's CPU% to its history,
+// starting over whenever the selection has moved to a different PID
+func (pl *ProcessList) recordCPUHistory() {
+	info, ok := pl.Selected()
+	if !ok {
+		return
+	}
+	if info.PID != pl.historyPID {
+		pl.cpuHistory = pl.cpuHistory[:0]
+		pl.historyPID = info.PID
+	}
+	pl.cpuHistory = append(pl.cpuHistory, info.CPU)
+	if len(pl.cpuHistory) > cpuHistoryLength {
+		pl.cpuHistory = pl.cpuHistory[len(pl.cpuHistory)-cpuHistoryLength:]
+	}
+}
+
+// CPUHistory returns the recent CPU% samples for pid, or nil if pid is not
+// the process being sampled
+func (pl *ProcessList) CPUHistory(pid int32) []float64 {
+	if pid != pl.historyPID {
+		return nil
+	}
+	return pl.cpuHistory
+}
+
+// refreshRows rebuilds the visible table rows from Processes without
+// re-collecting, so navigation keys can redraw immediately.
+func (pl *ProcessList) refreshRows() {
+	// Update column widths based on current width
+	pl.updateColumnWidths()
+
+	pl.restoreSelection()
+
+	// Update table rows
+	rows := make([][]string, 0)
+	rows = append(rows, pl.headerRow())
+
+	// Add process rows for the visible window only
+	end := pl.offset + pl.visibleRows()
+	if end > len(pl.Processes) {
+		end = len(pl.Processes)
+	}
+	columns := pl.visibleColumns()
+	for _, p := range pl.Processes[pl.offset:end] {
+		// Shorten the command before the column truncates it to fit
+		p.Command = commandText(p, pl.CommandMode)
+		row := make([]string, len(columns))
+		for i, col := range columns {
+			row[i] = alignCell(col, col.Value(p, pl.ColumnWidths[i]))
+			if col.Color != nil {
+				if color := col.Color(p); color != "" {
+					row[i] = fmt.Sprintf("[%s](fg:%s)", row[i], color)
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	pl.Rows = rows
+
+	// Color zombies red, grey out exited pins, mark processes listed only
+	// because they were jumped to, and highlight the selected row
+	// (row 0 is the header)
+	pl.RowStyles = make(map[int]ui.Style)
+	for i, p := range pl.Processes[pl.offset:end] {
+		if p.Exited {
+			pl.RowStyles[i+1] = ui.NewStyle(theme.Dim)
+		} else if pl.revealed[p.PID] {
+			pl.RowStyles[i+1] = ui.NewStyle(theme.Label)
+		} else if p.Status == process.Zombie {
+			pl.RowStyles[i+1] = ui.NewStyle(theme.Crit)
+		}
+	}
+	if len(pl.Processes) > 0 {
+		row := pl.selected - pl.offset + 1
+		fg := theme.Text
+		if style, ok := pl.RowStyles[row]; ok {
+			fg = style.Fg
+		}
+		pl.RowStyles[row] = ui.NewStyle(fg, ui.ColorClear, ui.ModifierReverse)
+	}
+}
+
+// formatCount formats a thread or descriptor count, showing "-" when unknown
+func formatCount(n int32) string {
+	if n < 0 {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
+
+// formatCPUTime formats seconds of CPU time as H:MM:SS, like top's TIME+
+func formatCPUTime(seconds float64) string {
+	total := int64(seconds)
+	return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+}
+
+// formatAge formats the time since created compactly, e.g. "3d4h", "12m", or
+// "45s". Clock skew that would give a negative age clamps to 0s.
+func formatAge(created time.Time) string {
+	if created.IsZero() {
+		return "-"
+	}
+	age := time.Since(created)
+	if age < 0 {
+		age = 0
+	}
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(age.Hours()), int(age.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(age.Hours())/24, int(age.Hours())%24)
+	}
+}
+
+// formatUptime formats an uptime in seconds to its two largest units, e.g.
+// "14d 3h" or "2h 5m"
+func formatUptime(seconds uint64) string {
+	up := time.Duration(seconds) * time.Second
+	switch {
+	case up < time.Hour:
+		return fmt.Sprintf("%dm", int(up.Minutes()))
+	case up < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm", int(up.Hours()), int(up.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd %dh", int(up.Hours())/24, int(up.Hours())%24)
+	}
+}
+
+// formatRate formats a bytes/sec rate, showing "-" when unavailable
+func formatRate(bps float64) string {
+	if bps < 0 {
+		return "-"
+	}
+	return formatBytes(uint64(bps)) + "/s"
+}
+
+// formatSwitchRate formats a context switch rate, in thousands above 1000,
+// showing "-" when unavailable
+func formatSwitchRate(rate float64) string {
+	switch {
+	case rate < 0:
+		return "-"
+	case rate >= 1000:
+		return fmt.Sprintf("%.1fk/s", rate/1000)
+	default:
+		return fmt.Sprintf("%.0f/s", rate)
+	}
+}
+
+// stateMarker returns the one-letter marker shown for zombie and stopped
+// processes, matching the state letters used by ps and top
+func stateMarker(status string) string {
+	switch status {
+	case process.Zombie:
+		return "Z"
+	case process.Stop:
+		return "T"
+	}
+	return ""
+}
+
+// niceUnknown marks a nice value that was not read or could not be read
+const niceUnknown = math.MinInt32
+
+// formatNice formats a nice value, showing "-" when unknown
+func formatNice(nice int32) string {
+	if nice == niceUnknown {
+		return "-"
+	}
+	return fmt.Sprint(nice)
+}
+
+// elevatedPriority reports whether nice schedules a process ahead of the
+// default. On Windows Nice reports the priority class's base priority,
+// where normal is 8 and higher values are more urgent.
+func elevatedPriority(nice int32) bool {
+	if nice == niceUnknown {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return nice > 8
+	}
+	return nice < 0
+}
+
+// visibleRows returns how many process rows fit below the header, accounting
+// for the separator line the table draws between rows.
+func (pl *ProcessList) visibleRows() int {
+	height := pl.Inner.Dy()
+	if pl.RowSeparator {
+		height = (height + 1) / 2
+	}
+	if height < 2 {
+		return 1
+	}
+	return height - 1
+}
+
+// restoreSelection moves the selection back onto SelectedPID after a re-sort,
+// or keeps the same index if that process has exited, then scrolls so the
+// selected row is visible.
+func (pl *ProcessList) restoreSelection() {
+	if len(pl.Processes) == 0 {
+		pl.selected, pl.offset = 0, 0
+		return
+	}
+	for i, p := range pl.Processes {
+		if p.PID == pl.SelectedPID {
+			pl.selected = i
+			break
+		}
+	}
+	pl.selectIndex(pl.selected)
+}
+
+// selectIndex clamps i to the process list, records the selected PID, and
+// adjusts the scroll offset to keep the selection in view.
+func (pl *ProcessList) selectIndex(i int) {
+	if len(pl.Processes) == 0 {
+		return
+	}
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(pl.Processes) {
+		i = len(pl.Processes) - 1
+	}
+	pl.selected = i
+	pl.SelectedPID = pl.Processes[i].PID
+
+	visible := pl.visibleRows()
+	if pl.selected < pl.offset {
+		pl.offset = pl.selected
+	} else if pl.selected >= pl.offset+visible {
+		pl.offset = pl.selected - visible + 1
+	}
+	if pl.offset > len(pl.Processes)-visible {
+		pl.offset = len(pl.Processes) - visible
+	}
+	if pl.offset < 0 {
+		pl.offset = 0
+	}
+}
+
+// ScrollAmount moves the selection by amount rows (negative moves up)
+func (pl *ProcessList) ScrollAmount(amount int) {
+	pl.navigated = time.Now()
+	pl.childrenOf = 0
+	if len(pl.revealed) > 0 {
+		// Moving on drops processes that were only listed to jump to them
+		clear(pl.revealed)
+		pl.applyFilter()
+		pl.sortProcesses()
+	}
+	pl.selectIndex(pl.selected + amount)
+	pl.refreshRows()
+}
+
+// SelectParent moves the selection to the selected process's parent
+func (pl *ProcessList) SelectParent() error {
+	info, ok := pl.Selected()
+	if !ok {
+		return nil
+	}
+	if pl.Grouped || pl.ByUser {
+		return errors.New("parent and children are only available for individual processes")
+	}
+	if info.PPID == 0 {
+		return fmt.Errorf("%s (PID %d) has no parent", info.Name, info.PID)
+	}
+	pl.childrenOf = 0
+	return pl.selectPID(info.PPID)
+}
+
+// SelectNextChild moves the selection to a child of the selected process.
+// Pressing it again cycles through the remaining children, in PID order.
+func (pl *ProcessList) SelectNextChild() error {
+	info, ok := pl.Selected()
+	if !ok {
+		return nil
+	}
+	if pl.Grouped || pl.ByUser {
+		return errors.New("parent and children are only available for individual processes")
+	}
+	parent := info.PID
+	if pl.childrenOf != 0 && info.PPID == pl.childrenOf {
+		// Already on one of the children, so go on to the next
+		parent = pl.childrenOf
+	}
+
+	var children []int32
+	for _, p := range pl.collected {
+		if p.PPID == parent {
+			children = append(children, p.PID)
+		}
+	}
+	if len(children) == 0 {
+		return fmt.Errorf("%s (PID %d) has no children", info.Name, info.PID)
+	}
+	slices.Sort(children)
+
+	next := children[0]
+	if parent != info.PID {
+		i := slices.Index(children, info.PID)
+		next = children[(i+1)%len(children)]
+	}
+	pl.childrenOf = parent
+	return pl.selectPID(next)
+}
+
+// selectPID moves the selection to pid. A process hidden by the filters is
+// listed anyway, highlighted, until the selection moves on.
+func (pl *ProcessList) selectPID(pid int32) error {
+	listed := slices.ContainsFunc(pl.Processes, func(p ProcessInfo) bool { return p.PID == pid })
+	if !listed {
+		collected := slices.ContainsFunc(pl.collected, func(p ProcessInfo) bool { return p.PID == pid })
+		if !collected {
+			return fmt.Errorf("PID %d is not being collected (press H if it is a kernel thread)", pid)
+		}
+		pl.revealed[pid] = true
+		pl.applyFilter()
+		pl.sortProcesses()
+	}
+	pl.SelectedPID = pid
+	pl.refreshRows()
+	return nil
+}
+
+// Selected returns the highlighted process, if any
+func (pl *ProcessList) Selected() (ProcessInfo, bool) {
+	if pl.selected < 0 || pl.selected >= len(pl.Processes) {
+		return ProcessInfo{}, false
+	}
+	return pl.Processes[pl.selected], true
+}
+
+// SelectAt moves the selection to the process drawn on screen row y,
+// reporting false if no process is drawn there
+func (pl *ProcessList) SelectAt(y int) bool {
+	line := y - pl.Inner.Min.Y
+	if pl.RowSeparator {
+		if line%2 != 0 {
+			return false
+		}
+		line /= 2
+	}
+	i := pl.offset + line - 1 // Line 0 is the header
+	if line < 1 || line > pl.visibleRows() || i >= len(pl.Processes) {
+		return false
+	}
+	pl.ScrollAmount(i - pl.selected)
+	return true
+}
+
+// ColumnAt returns the column whose header is drawn at the screen point
+// x, y, if any
+func (pl *ProcessList) ColumnAt(x, y int) (*processColumn, bool) {
+	if y != pl.Inner.Min.Y || x < pl.Inner.Min.X || x >= pl.Inner.Max.X {
+		return nil, false
+	}
+	left := pl.Inner.Min.X
+	for i, col := range pl.visibleColumns() {
+		// Each column owns the separator to its right
+		left += pl.ColumnWidths[i] + 1
+		if x < left {
+			return col, true
+		}
+	}
+	return nil, false
+}
+
+func (pl *ProcessList) ScrollUp() {
+	pl.ScrollAmount(-1)
+}
+
+func (pl *ProcessList) ScrollDown() {
+	pl.ScrollAmount(1)
+}
+
+func (pl *ProcessList) ScrollPageUp() {
+	pl.ScrollAmount(-pl.visibleRows())
+}
+
+func (pl *ProcessList) ScrollPageDown() {
+	pl.ScrollAmount(pl.visibleRows())
+}
+
+func (pl *ProcessList) ScrollTop() {
+	pl.ScrollAmount(-len(pl.Processes))
+}
+
+func (pl *ProcessList) ScrollBottom() {
+	pl.ScrollAmount(len(pl.Processes))
+}
+
+// CPUGauge tracks a CPU gauge with its previous value and target value for smooth transitions
+type CPUGauge struct {
+	*widgets.Gauge
+	Spark          *widgets.Paragraph // Sparkline drawn instead of the gauge in sparkline mode
+	History        *cpuHistory        // Recent utilization samples for the sparkline
+	Label          string             // Title without the frequency suffix
+	Detail         string             // Text shown after the percentage inside the gauge
+	CurrentPercent float64            // Current displayed value (for smooth transitions)
+	TargetPercent  float64            // Target value to animate towards
+}
+
+// CPUData stores CPU usage history for graphing
+type CPUData struct {
+	AvgData  []float64     // History of average CPU usage
+	PeakData []float64     // History of the busiest core's usage
+	MaxValue float64       // Maximum value for scaling
+	Interval time.Duration // Time between samples
+}
+
+// NetworkData stores network traffic data for graphing
+type NetworkData struct {
+	RxData   []float64     // History of received data rates
+	TxData   []float64     // History of transmitted data rates
+	MaxValue float64       // Maximum value for scaling
+	RxMax    float64       // Maximum received rate, for scaling its own graph when split
+	TxMax    float64       // Maximum sent rate, for scaling its own graph when split
+	LogScale bool          // Plot the history on a log scale; the data itself stays raw
+	Split    bool          // Plot received and sent traffic on separate graphs
+	Interval time.Duration // Time between samples
+}
+
+// DiskData stores disk I/O data for graphing
+type DiskData struct {
+	ReadData  []float64     // History of read speeds
+	WriteData []float64     // History of write speeds
+	MaxValue  float64       // Maximum value for scaling
+	ReadMax   float64       // Maximum read speed, for scaling its own graph when split
+	WriteMax  float64       // Maximum write speed, for scaling its own graph when split
+	LogScale  bool          // Plot the history on a log scale; the data itself stays raw
+	Split     bool          // Plot reads and writes on separate graphs
+	Device    string        // Device the history is of, or "all"
+	Busiest   string        // Busiest device and how busy, e.g. "sda 97%", or "" when unknown
+	Interval  time.Duration // Time between samples
+}
+
+func main() {
+	showVersion := flag.Bool("version", false, "Show version information")
+	showKernel := flag.Bool("show-kernel", false, "Show kernel threads in the process list")
+	filterPattern := flag.String("filter", "", "Only list processes whose name or command matches this regexp")
+	filterInvert := flag.Bool("filter-invert", false, "Hide processes matching --filter instead of showing only them")
+	caseSensitive := flag.Bool("case-sensitive", false, "Match --filter and the / filter case-sensitively")
+	ownerSpec := flag.String("user", "", "Only list processes owned by this user name or UID (o toggles it)")
+	tempWarn := flag.Float64("temp-warn", defaultTempWarning, "CPU temperature in °C above which it is shown in red")
+	cpuWarn := flag.Float64("cpu-warn", defaultCPUThresholds.Warn, "CPU utilization percentage at which gauges turn yellow")
+	cpuCrit := flag.Float64("cpu-crit", defaultCPUThresholds.Crit, "CPU utilization percentage at which gauges turn red")
+	diskAwaitWarn := flag.Float64("disk-await-warn", defaultAwaitThresholds.Warn, "Disk request latency in milliseconds at which it turns yellow")
+	diskAwaitCrit := flag.Float64("disk-await-crit", defaultAwaitThresholds.Crit, "Disk request latency in milliseconds at which it turns red")
+	psiWarn := flag.Float64("psi-warn", defaultPSIThresholds.Warn, "Memory pressure (PSI) percentage at which it turns yellow")
+	psiCrit := flag.Float64("psi-crit", defaultPSIThresholds.Crit, "Memory pressure (PSI) percentage at which it turns red")
+	interval := flag.Duration("interval", time.Second, "How often to collect new readings, e.g. 500ms or 2s")
+	procEvery := flag.Int("proc-every", 1, "Refresh the process list every this many readings")
+	netUnits := flag.String("units", netUnitNames[NetBits], "Units for network rates ("+strings.Join(netUnitNames, ", ")+")")
+	excludeDisks := flag.String("exclude-disks", strings.Join(defaultExcludedDisks, ","), "Comma-separated disk devices to leave out, globs allowed")
+	allDisks := flag.Bool("all-disks", false, "Report every disk device, including those --exclude-disks leaves out")
+	rootMount := flag.String("root", defaultHeaderRoot(), "Mount point whose free space the header shows")
+	freeMount := flag.String("free-mount", "", "Mount point the free space graph plots (default: the --root mount)")
+	diskPartitions := flag.Bool("disk-partitions", false, "Report disk partitions instead of the whole disks holding them")
+	diskName := flag.String("disk", "", "Disk device to graph, e.g. nvme0n1 (default: every device)")
+	pingSpec := flag.String("ping", "", "Host to show round-trip times to, probed once a second; host:port probes over TCP")
+	allIfaces := flag.Bool("all-interfaces", false, "Count loopback, container, and VM bridge interfaces in the network totals")
+	ifaceList := flag.String("iface", "", "Comma-separated network interfaces to report, globs allowed, e.g. eth0,wlan*")
+	cpuView := flag.String("cpu-view", coreViewNames[CoreGauges], "How to draw each CPU core ("+strings.Join(coreViewNames, ", ")+")")
+	noMouse := flag.Bool("no-mouse", false, "Leave the mouse to the terminal, so text can be selected as usual")
+	themeName := flag.String("theme", themeNames[0], "Color theme ("+strings.Join(themeNames, ", ")+")")
+	columnList := flag.String("columns", defaultColumns, "Comma-separated process table columns to show ("+strings.Join(columnNames(), ", ")+")")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("SysGoMon version %s\n", version)
+		os.Exit(0)
+	}
+
+	var pattern *regexp.Regexp
+	if *filterPattern != "" {
+		var err error
+		expr := *filterPattern
+		if !*caseSensitive {
+			expr = "(?i)" + expr
+		}
+		if pattern, err = regexp.Compile(expr); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --filter pattern %q: %v\n", *filterPattern, err)
+			os.Exit(2)
+		}
+	}
+
+	// o toggles between all processes and the --user owner, or the current
+	// user when --user isn't given
+	ownerUID, ownerName := currentUser()
+	if *ownerSpec != "" {
+		var err error
+		if ownerUID, ownerName, err = lookupUser(*ownerSpec); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --user %q: %v\n", *ownerSpec, err)
+			os.Exit(2)
+		}
+	}
+
+	if *interval < minInterval {
+		fmt.Fprintf(os.Stderr, "Invalid --interval %v: must be at least %v\n", *interval, minInterval)
+		os.Exit(2)
+	}
+	if *procEvery < 1 {
+		fmt.Fprintf(os.Stderr, "Invalid --proc-every %d: must be at least 1\n", *procEvery)
+		os.Exit(2)
+	}
+
+	cpuThresholds := usageThresholds{Warn: *cpuWarn, Crit: *cpuCrit}
+	if err := cpuThresholds.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --cpu-warn/--cpu-crit: %v\n", err)
+		os.Exit(2)
+	}
+
+	psiThresholds := usageThresholds{Warn: *psiWarn, Crit: *psiCrit}
+	if err := psiThresholds.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --psi-warn/--psi-crit: %v\n", err)
+		os.Exit(2)
+	}
+
+	awaitThresholds := latencyThresholds{Warn: *diskAwaitWarn, Crit: *diskAwaitCrit}
+	if err := awaitThresholds.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --disk-await-warn/--disk-await-crit: %v\n", err)
+		os.Exit(2)
+	}
+
+	ifacePatterns, err := parsePatternList(*ifaceList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --iface %q: %v\n", *ifaceList, err)
+		os.Exit(2)
+	}
+
+	var target pingTarget
+	if *pingSpec != "" {
+		if target, err = parsePingTarget(*pingSpec); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --ping %q: %v\n", *pingSpec, err)
+			os.Exit(2)
+		}
+	}
+
+	diskDevices := diskFilter{Partitions: *diskPartitions}
+	if !*allDisks {
+		diskDevices.Exclude, err = parsePatternList(*excludeDisks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --exclude-disks %q: %v\n", *excludeDisks, err)
+			os.Exit(2)
+		}
+	}
+	if *diskName != "" {
+		if counters, err := disk.IOCounters(); err == nil {
+			counters = diskDevices.Apply(counters)
+			if _, ok := counters[*diskName]; !ok {
+				names := make([]string, 0, len(counters))
+				for name := range counters {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				fmt.Fprintf(os.Stderr, "Invalid --disk %q: no such device (devices: %s)\n", *diskName, strings.Join(names, ", "))
+				os.Exit(2)
+			}
+		}
+	}
+
+	netUnit, err := parseNetUnit(*netUnits)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --units %q: %v\n", *netUnits, err)
+		os.Exit(2)
+	}
+
+	coreView, err := parseCoreView(*cpuView)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --cpu-view %q: %v\n", *cpuView, err)
+		os.Exit(2)
+	}
+
+	columns, err := parseColumns(*columnList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --columns value %q: %v\n", *columnList, err)
+		os.Exit(2)
+	}
+
+	if theme, err = parseTheme(*themeName); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --theme %q: %v\n", *themeName, err)
+		os.Exit(2)
+	}
+
+	if err := ui.Init(); err != nil {
+		log.Fatalf("Failed to initialize termui: %v", err)
+	}
+	defer ui.Close()
+	if *noMouse {
+		// termui reports mouse events by default, which stops the
+		// terminal selecting text with the mouse
+		termbox.SetInputMode(termbox.InputEsc)
+	}
+
+	// Widgets take their colors from the theme as they are created
+	theme.install()
+
+	// Set the animation speed (lower = slower transitions)
+	animationSpeed := 0.2 // How quickly to transition to target value
+
+	// Get the terminal dimensions
+	termWidth, termHeight := ui.TerminalDimensions()
+
+	// Create header with system info
+	header := widgets.NewParagraph()
+	header.Title = "SysGoMon"
+	header.Border = true
+	header.TextStyle.Fg = theme.Label
+	header.TitleStyle.Fg = theme.Title
+
+	// Create CPU gauges and the summary line above them
+	cpuDisplay := createCPUDisplay()
+	cpuDisplay.Thresholds = cpuThresholds
+	cpuDisplay.View = coreView
+
+	// The history graphs keep as many samples as they have columns, so they
+	// span the whole width with the newest at the right edge
+	dataPointCount := historyPoints(termWidth)
+
+	// CPU graph for historical data
+	cpuGraph := widgets.NewPlot()
+	cpuGraph.Title = "CPU History (%)"
+	cpuGraph.Border = true
+	cpuGraph.LineColors[0] = theme.CPUAvg      // Average
+	cpuGraph.LineColors[1] = theme.CPUPeak     // Busiest core
+	cpuGraph.DrawDirection = widgets.DrawRight // Draw from left to right
+	cpuGraph.TitleStyle.Fg = theme.Title
+	cpuGraph.Data = make([][]float64, 2)
+	cpuGraph.Data[0] = make([]float64, dataPointCount) // Average data
+	cpuGraph.Data[1] = make([]float64, dataPointCount) // Busiest core data
+	cpuGraph.PlotType = widgets.LineChart              // Use line chart for better visibility
+	cpuGraph.ShowAxes = false                          // Hide the axis numbers
+	cpuGraph.HorizontalScale = 1.0                     // Ensure it uses full width
+	cpuGraph.AxesColor = ui.ColorClear                 // Make axes invisible
+
+	// CPU usage history
+	cpuData := CPUData{
+		AvgData:  make([]float64, dataPointCount),
+		PeakData: make([]float64, dataPointCount),
+		MaxValue: 0.1, // Start with a small non-zero value
+		Interval: *interval,
+	}
+
+	// Readings passed between sections, such as the top CPU consumer from
+	// the process snapshots to the average gauge
+	state := &sharedState{}
+
+	// Where the average CPU's time goes, shown inside its gauge
+	cpuTimes := &cpuBreakdown{}
+	cpuTimes.Update()
+	cpuSummary := createCPUSummary(len(cpuDisplay.Gauges) - 1)
+	cpuSummary.TempWarn = *tempWarn
+	cpuSummary.Update()
+
+	// Sensors are slow to read on some platforms, so the CPU temperature is
+	// read once at startup and again only when asked for
+	temperatures := make(chan temperatureReading, 1)
+	readTemperature(temperatures)
+
+	// Create memory gauge and graph
+	memory := createMemorySection(dataPointCount)
+	memory.Data.Interval = *interval
+	memory.Thresholds = cpuThresholds
+	memory.PSI = psiThresholds
+
+	// Create Network stats and graph
+	netSelect := &netSelection{Patterns: ifacePatterns}
+	if len(ifacePatterns) == 0 && !*allIfaces {
+		netSelect.Exclude = defaultExcludedInterfaces
+	}
+	links := newLinkCache()
+	netStats := widgets.NewParagraph()
+	netStats.Title = netSelect.Title(links)
+	netStats.Border = true
+	netStats.TitleStyle.Fg = theme.Title
+
+	// Network graph for historical data
+	netGraph := widgets.NewPlot()
+	netGraph.Title = fmt.Sprintf("Network Traffic History (%s)", netUnit.GraphUnit())
+	netGraph.Border = true
+	netGraph.LineColors[0] = theme.Rx // RX
+	netGraph.LineColors[1] = theme.Tx // TX
+	netGraph.AxesColor = theme.Border
+	netGraph.DrawDirection = widgets.DrawRight // Draw from left to right
+	netGraph.TitleStyle.Fg = theme.Title
+	netGraph.Data = make([][]float64, 2)
+	netGraph.Data[0] = make([]float64, dataPointCount) // RX data with terminal-width adjusted count
+	netGraph.Data[1] = make([]float64, dataPointCount) // TX data with terminal-width adjusted count
+	netGraph.PlotType = widgets.LineChart              // Use line chart for better visibility
+	netGraph.ShowAxes = false                          // Hide the axis numbers
+	netGraph.HorizontalScale = 1.0                     // Ensure it uses full width
+	// Set plot mode to stretch to fill the entire width
+	netGraph.AxesColor = ui.ColorClear // Make axes invisible
+
+	// Sent traffic, on its own graph below netGraph while the graphs are
+	// split
+	netOutGraph := createSplitGraph(theme.Tx, dataPointCount)
+
+	ifaceTable := createInterfaceTable()
+	ifaceTable.Unit = netUnit
+	ifaceTable.Links = links
+
+	// Retransmission and reset rates, on an extra line of the network stats
+	// where the kernel's TCP counters are available
+	tcpHealth := newTCPHealth()
+
+	// Round-trip times to the --ping host, beside the network stats. Probes
+	// run off the UI goroutine, so a slow or unreachable host never stalls it.
+	var pinger *Pinger
+	var pingPanel *PingPanel
+	var pingResults <-chan pingResult
+	if target.Host != "" {
+		pinger = NewPinger(target)
+		pingPanel = createPingPanel(pinger)
+		pingResults = pinger.Results
+	}
+
+	// Network traffic history
+	netData := NetworkData{
+		RxData:   make([]float64, dataPointCount),
+		TxData:   make([]float64, dataPointCount),
+		MaxValue: 0.1, // Start with a small non-zero value
+		RxMax:    0.1,
+		TxMax:    0.1,
+		Interval: *interval,
+	}
+
+	// Create Disk I/O stats and graph
+	diskStats := widgets.NewParagraph()
+	diskStats.Title = "Disk I/O"
+	diskStats.Border = true
+	diskStats.TitleStyle.Fg = theme.Title
+
+	// Disk I/O graph for historical data
+	diskGraph := widgets.NewPlot()
+	diskGraph.Title = "Disk I/O History (MB/s)"
+	diskGraph.Border = true
+	diskGraph.LineColors[0] = theme.Read  // Read
+	diskGraph.LineColors[1] = theme.Write // Write
+	diskGraph.AxesColor = theme.Border
+	diskGraph.DrawDirection = widgets.DrawRight // Draw from left to right
+	diskGraph.TitleStyle.Fg = theme.Title
+	diskGraph.Data = make([][]float64, 2)
+	diskGraph.Data[0] = make([]float64, dataPointCount) // Read data
+	diskGraph.Data[1] = make([]float64, dataPointCount) // Write data
+	diskGraph.PlotType = widgets.LineChart              // Use line chart for better visibility
+	diskGraph.ShowAxes = false                          // Hide the axis numbers
+	diskGraph.HorizontalScale = 1.0                     // Ensure it uses full width
+	diskGraph.AxesColor = ui.ColorClear                 // Make axes invisible
+
+	// Device feeding the disk graph
+	diskSelect := &diskSelection{Selected: *diskName}
+
+	// Get initial disk stats for baseline, which also sizes the disk stats
+	// paragraph to the number of devices
+	diskTraffic := newDiskTracker(diskDevices, awaitThresholds)
+	if diskIOCounters, err := disk.IOCounters(); err == nil {
+		diskTraffic.Update(diskIOCounters, diskSelect)
+	} else {
+		log.Printf("Error getting disk I/O stats: %v", err)
+	}
+
+	// Writes, on their own graph below diskGraph while the graphs are split
+	diskWriteGraph := createSplitGraph(theme.Write, dataPointCount)
+
+	// Disk I/O history
+	diskData := DiskData{
+		ReadData:  make([]float64, dataPointCount),
+		WriteData: make([]float64, dataPointCount),
+		MaxValue:  0.1, // Start with a small non-zero value
+		ReadMax:   0.1,
+		WriteMax:  0.1,
+		Device:    diskSelect.Label(),
+		Interval:  *interval,
+	}
+
+	// Create process list
+	processList := createProcessList(0, 0, termWidth, termHeight-1, columns)
+	processList.ShowKernel = *showKernel
+	processList.Pattern = pattern
+	processList.PatternInvert = *filterInvert
+	processList.CaseSensitive = *caseSensitive
+	processList.OwnerOnly = *ownerSpec != ""
+	processList.OwnerUID, processList.OwnerName = ownerUID, ownerName
+	processList.TitleStyle.Fg = theme.Title
+
+	// Create footer with instructions
+	footer := createStatusFooter("[Press q to quit](fg:crit)")
+
+	// Confirmation overlay for killing the selected process
+	killPrompt := createKillPrompt()
+
+	// Outcomes of process tree kills, which run off the UI goroutine
+	killResults := make(chan killTreeResult, 1)
+
+	// Overlay for showing and hiding process table columns
+	columnMenu := createColumnMenu()
+
+	// Overlay listing the key bindings, the version, and the interfaces the
+	// network totals leave out
+	help := createHelpPanel()
+
+	// Footer input for the interactive process filter
+	filterInput := &LineInput{Prompt: "Filter: "}
+
+	// Detail panel for the selected process, drawn over the disk section
+	processDetail := createProcessDetail()
+
+	// Processes using the network most, drawn over the disk section while
+	// open
+	netProcs := createNetProcessPanel()
+	netProcs.Unit = netUnit
+	defer netProcs.Close()
+
+	// Listening sockets, drawn over the disk section in place of the
+	// network processes while open
+	listeners := createListenerPanel()
+	defer listeners.Close()
+
+	// Filesystem space and inode usage, likewise drawn over the disk section.
+	// Collected all session, so the time until full covers more than the
+	// time the panel is open.
+	filesystems := createFilesystemPanel()
+	filesystems.Start()
+	defer filesystems.Stop()
+
+	// Software RAID arrays, likewise drawn over the disk section. Trouble
+	// with any of them is also flagged on the disk section's title.
+	raid := createRAIDPanel()
+	raid.Update()
+	showRAIDAlert := func() {
+		diskStats.Title = "Disk I/O"
+		diskStats.TitleStyle.Fg = theme.Title
+		if trouble := raid.Troubled(); trouble != "" {
+			diskStats.Title = fmt.Sprintf("Disk I/O - RAID %s (A for details)", trouble)
+			diskStats.TitleStyle.Fg = theme.Crit
+		}
+	}
+	showRAIDAlert()
+
+	// closeDiskPanels closes whichever panel is open over the disk section,
+	// since only one is shown at a time
+	closeDiskPanels := func() {
+		netProcs.Close()
+		listeners.Close()
+		filesystems.Close()
+		raid.Close()
+	}
+
+	// coveredByPanel reports whether pt lies under an open panel
+	coveredByPanel := func(pt image.Point) bool {
+		panels := []struct {
+			active bool
+			widget ui.Drawable
+		}{
+			{processDetail.Active, processDetail},
+			{netProcs.Active, netProcs},
+			{listeners.Active, listeners},
+			{filesystems.Active, filesystems},
+			{raid.Active, raid},
+		}
+		for _, p := range panels {
+			if p.active && placed[p.widget] && pt.In(p.widget.GetRect()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Free space history of one mount, drawn in place of the disk I/O graph
+	// while shown
+	if *freeMount == "" {
+		*freeMount = *rootMount
+	}
+	freeSpace := createFreeSpaceGraph(*freeMount)
+
+	// Which sections are shown; the number keys hide and show them
+	shown := [sectionCount]bool{true, true, true, true}
+
+	// The layout the terminal has room for, and the widgets only the
+	// smaller layouts use
+	mode := layoutFull
+	compactNet, compactDisk := createCompactLine(), createCompactLine()
+	tooSmall := createTooSmallNotice()
+
+	// centerOverlays positions the open overlays in the middle of what
+	// they're drawn over, once layout has positioned that
+	centerOverlays := func() {
+		if killPrompt.Active {
+			killPrompt.Center(processList.Block.Rectangle)
+		}
+		if columnMenu.Active {
+			columnMenu.Center(processList.Block.Rectangle)
+		}
+		if help.Active {
+			help.Center(image.Rect(0, 0, termWidth, termHeight))
+		}
+	}
+
+	// layout positions the shown sections for the current terminal size, and
+	// is re-run whenever the size or a section's height changes, such as when
+	// the cores are collapsed or a section is hidden. Hidden sections aren't
+	// positioned, and the space they leave goes to the process list, or to
+	// the graphs when the process list is hidden too. Short of room, the
+	// graphs are squeezed first; when even that isn't enough, the compact
+	// layout takes over. Only the widgets positioned are drawn, and the
+	// sections keep collecting throughout, so their history is intact when
+	// the full layout returns. Every rect is set here, overlays included, so
+	// no widget is left where an earlier size put it.
+	layout := func() {
+		clear(placed)
+		previous := mode
+
+		netStatsRows := netStatsHeight
+		if tcpHealth.Available {
+			netStatsRows++
+		}
+		diskStatsRows := diskStatsHeight + diskTraffic.Lines() - 2
+		cpuGraphRows, netGraphRows, diskGraphRows := cpuGraphHeight, sectionGraphHeight, sectionGraphHeight
+
+		// Rows the sections below the CPU display need besides their graphs,
+		// which are squeezed if the sections don't fit at full height. The
+		// panels drawn over the disk section take its place while it's
+		// hidden.
+		panelOpen := processDetail.Active || netProcs.Active || listeners.Active || filesystems.Active || raid.Active
+		rest := memory.Height() + footerHeight
+		var graphs []*int
+		if shown[sectionCPU] {
+			graphs = append(graphs, &cpuGraphRows)
+		}
+		if shown[sectionNetwork] {
+			rest += netStatsRows
+			graphs = append(graphs, &netGraphRows)
+		}
+		if shown[sectionDisk] {
+			rest += diskStatsRows
+			graphs = append(graphs, &diskGraphRows)
+		} else if panelOpen {
+			rest += diskStatsHeight
+			graphs = append(graphs, &diskGraphRows)
+		}
+		if shown[sectionProcesses] {
+			rest += minProcessListHeight
+		}
+		graphRows := func() int {
+			rows := 0
+			for _, h := range graphs {
+				rows += *h
+			}
+			return rows
+		}
+
+		// The header, and the summary line and average gauge above the cores
+		top := 3
+		if shown[sectionCPU] {
+			top = 7
+		}
+		cpuRows := top + cpuDisplay.MinHeight(termWidth)
+		shrinkGraphs(cpuRows+rest+graphRows()-termHeight, graphs...)
+		switch {
+		case termWidth < minTermWidth || termHeight < minTermHeight:
+			mode = layoutTooSmall
+		case termWidth < fullMinWidth || cpuRows+rest+graphRows() > termHeight:
+			mode = layoutCompact
+		default:
+			mode = layoutFull
+		}
+		if mode == layoutCompact && previous != layoutCompact {
+			footer.SetStatus("[Compact layout; hide sections (1-4) or enlarge the terminal](fg:warn)")
+		}
+
+		switch mode {
+		case layoutTooSmall:
+			tooSmall.SetRect(0, 0, termWidth, termHeight)
+			place(tooSmall)
+			return
+		case layoutCompact:
+			// The average gauge, a line each for the network and disk
+			// rates, and the process list without row separators, which
+			// an open panel takes the place of
+			header.SetRect(0, 0, termWidth, 3)
+			top = 3
+			if shown[sectionCPU] {
+				avg := cpuDisplay.Gauges[0].Gauge
+				avg.SetRect(0, top, termWidth, top+3)
+				place(avg)
+				top += 3
+			}
+			if shown[sectionNetwork] {
+				compactNet.SetRect(0, top, termWidth, top+1)
+				place(compactNet)
+				top++
+			}
+			if shown[sectionDisk] {
+				compactDisk.SetRect(0, top, termWidth, top+1)
+				place(compactDisk)
+				top++
+			}
+			switch {
+			case panelOpen:
+				processDetail.SetRect(0, top, termWidth, termHeight-footerHeight)
+				netProcs.SetRect(0, top, termWidth, termHeight-footerHeight)
+				listeners.SetRect(0, top, termWidth, termHeight-footerHeight)
+				filesystems.SetRect(0, top, termWidth, termHeight-footerHeight)
+				raid.SetRect(0, top, termWidth, termHeight-footerHeight)
+				place(processDetail, netProcs, listeners, filesystems, raid)
+			case shown[sectionProcesses]:
+				processList.RowSeparator = false
+				processList.SetRect(0, top, termWidth, termHeight-footerHeight)
+				processList.refreshRows()
+				place(processList)
+			}
+			if shown[sectionProcesses] {
+				place(killPrompt, columnMenu)
+			}
+			footer.SetRect(0, termHeight-footerHeight, termWidth, termHeight)
+			place(header, footer, help)
+			centerOverlays()
+			return
+		}
+
+		header.SetRect(0, 0, termWidth, 3)
+		place(header, help)
+		below := rest + graphRows()
+		if shown[sectionCPU] {
+			cpuSummary.SetRect(0, 3, termWidth, 4)
+			top = cpuDisplay.Layout(termWidth, termHeight-below)
+			place(cpuSummary, cpuGraph)
+			place(cpuDisplay.Drawables()...)
+		}
+		if !shown[sectionProcesses] {
+			growGraphs(termHeight-top-below, graphs...)
+		}
+
+		if shown[sectionCPU] {
+			cpuGraph.SetRect(0, top, termWidth, top+cpuGraphRows)
+			top += cpuGraphRows
+		}
+		top = memory.Layout(top, termWidth)
+		place(memory.Drawables()...)
+
+		ifaceTable.Visible = shown[sectionNetwork] && termWidth >= minIfaceTableTermWidth
+		if shown[sectionNetwork] {
+			netTop := top + netStatsRows
+			netStats.SetRect(0, top, termWidth, netTop)
+			if pingPanel != nil {
+				netStats.SetRect(0, top, termWidth-pingPanelWidth, netTop)
+				pingPanel.SetRect(termWidth-pingPanelWidth, top, termWidth, netTop)
+				place(pingPanel)
+			}
+			netGraphWidth := termWidth
+			if ifaceTable.Visible {
+				// The per-interface table takes the right of the graph's rows
+				netGraphWidth -= ifaceTableWidth
+				ifaceTable.SetRect(netGraphWidth, netTop, termWidth, netTop+netGraphRows)
+				place(ifaceTable)
+			}
+			top = netTop + netGraphRows
+			if netData.Split {
+				netGraph.SetRect(0, netTop, netGraphWidth, netTop+(netGraphRows+1)/2)
+				netOutGraph.SetRect(0, netTop+(netGraphRows+1)/2, netGraphWidth, top)
+			} else {
+				netGraph.SetRect(0, netTop, netGraphWidth, top)
+			}
+			place(netStats, netGraph, netOutGraph)
+		}
+
+		panelTop := top
+		if shown[sectionDisk] {
+			diskTop := top + diskStatsRows
+			diskStats.SetRect(0, top, termWidth, diskTop)
+			top = diskTop + diskGraphRows
+			if diskData.Split {
+				diskGraph.SetRect(0, diskTop, termWidth, diskTop+(diskGraphRows+1)/2)
+				diskWriteGraph.SetRect(0, diskTop+(diskGraphRows+1)/2, termWidth, top)
+			} else {
+				diskGraph.SetRect(0, diskTop, termWidth, top)
+			}
+			freeSpace.SetRect(0, diskTop, termWidth, top)
+			place(diskStats, diskGraph, diskWriteGraph, freeSpace)
+		} else if panelOpen {
+			top += diskStatsHeight + diskGraphRows
+		}
+		panelBottom := top
+		processDetail.SetRect(0, panelTop, termWidth, panelBottom)
+		netProcs.SetRect(0, panelTop, termWidth, panelBottom)
+		listeners.SetRect(0, panelTop, termWidth, panelBottom)
+		filesystems.SetRect(0, panelTop, termWidth, panelBottom)
+		raid.SetRect(0, panelTop, termWidth, panelBottom)
+		place(processDetail, netProcs, listeners, filesystems, raid)
+
+		if shown[sectionProcesses] {
+			processList.RowSeparator = true
+			processList.SetRect(0, top, termWidth, termHeight-footerHeight)
+			processList.refreshRows()
+			place(processList, killPrompt, columnMenu)
+		}
+
+		footer.SetRect(0, termHeight-footerHeight, termWidth, termHeight)
+		place(footer)
+		centerOverlays()
+	}
+
+	// redraw clears the screen and draws every section and open overlay the
+	// layout placed
+	redraw := func() {
+		ui.Clear()
+		render(header, compactNet, compactDisk, tooSmall)
+		if shown[sectionCPU] {
+			render(cpuSummary)
+			cpuDisplay.Render()
+			render(cpuGraph)
+		}
+		render(memory.Drawables()...)
+		if shown[sectionNetwork] {
+			if ifaceTable.Visible {
+				render(ifaceTable)
+			}
+			if pingPanel != nil {
+				render(pingPanel)
+			}
+			render(netStats, netGraph)
+			if netData.Split {
+				render(netOutGraph)
+			}
+		}
+		if shown[sectionDisk] {
+			render(diskStats, diskGraph)
+			if diskData.Split {
+				render(diskWriteGraph)
+			}
+			if freeSpace.Active {
+				render(freeSpace)
+			}
+		}
+		if shown[sectionProcesses] {
+			render(processList)
+		}
+		render(footer)
+		if netProcs.Active {
+			render(netProcs)
+		}
+		if listeners.Active {
+			render(listeners)
+		}
+		if filesystems.Active {
+			render(filesystems)
+		}
+		if raid.Active {
+			render(raid)
+		}
+		if processDetail.Active {
+			render(processDetail)
+		}
+		if killPrompt.Active {
+			render(killPrompt)
+		}
+		if columnMenu.Active {
+			render(columnMenu)
+		}
+		if help.Active {
+			render(help)
+		}
+	}
+
+	// renderDiskSection draws the disk section and whichever panels are
+	// open over it. While it's hidden the panels take its rows, so opening
+	// or closing one moves the sections below and everything is redrawn.
+	renderDiskSection := func() {
+		if !shown[sectionDisk] {
+			layout()
+			redraw()
+			return
+		}
+		render(diskStats, diskGraph)
+		if diskData.Split {
+			render(diskWriteGraph)
+		}
+		if freeSpace.Active {
+			render(freeSpace)
+		}
+		if netProcs.Active {
+			render(netProcs)
+		}
+		if listeners.Active {
+			render(listeners)
+		}
+		if filesystems.Active {
+			render(filesystems)
+		}
+		if raid.Active {
+			render(raid)
+		}
+		if processDetail.Active {
+			render(processDetail)
+		}
+	}
+
+	// Get initial network stats for baseline
+	netTraffic := newNetTracker()
+	if _, err := netTraffic.Sample(netSelect); err != nil {
+		log.Printf("Error getting network stats: %v", err)
+	}
+
+	// Update system info in header
+	cpuID := readCPUIdentity()
+	mounts := newHeaderMounts(*rootMount)
+	battery := detectBattery()
+	updateHeader(header, cpuID, mounts, battery, termWidth)
+	lastHeaderUpdate := time.Now()
+
+	// Initial layout and render to set up the screen
+	layout()
+	redraw()
+
+	// Collect processes off the UI goroutine
+	collector := NewProcessCollector()
+	collector.Start()
+	defer collector.Stop()
+
+	// List sockets off the UI goroutine, since it can be slow, and only
+	// while the network section is shown. Receiving from the nil channel
+	// while stopped blocks.
+	var connCollector *ConnectionCollector
+	var connSummaries chan tcpSummary
+	startConnections := func() {
+		connCollector = NewConnectionCollector()
+		connCollector.Start()
+		connSummaries = connCollector.Summaries
+	}
+	stopConnections := func() {
+		if connCollector != nil {
+			connCollector.Stop()
+			connCollector, connSummaries = nil, nil
+		}
+	}
+	startConnections()
+	defer stopConnections()
+
+	if pinger != nil {
+		pinger.Start()
+		defer pinger.Stop()
+	}
+	tcpConns := tcpSummary{States: map[string]int{}}
+
+	// Set up event handling
+	uiEvents := ui.PollEvents()
+	dataTicker := time.NewTicker(*interval) // Collect new readings
+	defer dataTicker.Stop()
+	animationTicker := time.NewTicker(animationInterval) // Step the gauge animations
+	defer animationTicker.Stop()
+
+	// A resize is applied once the terminal has settled on a size
+	var resized ui.Resize
+	var resizeSettled <-chan time.Time
+
+	readings := 0 // Readings taken, for --proc-every
+
+	// While paused, readings are neither taken nor shown, freezing every
+	// figure and graph; the UI still responds to keys and resizing
+	paused := false
+
+	// resyncRates takes a fresh baseline for every rate, so the first
+	// reading after a pause covers one interval rather than the whole pause
+	resyncRates := func() {
+		cpu.Percent(0, false)
+		cpu.Percent(0, true)
+		cpuTimes.Update()
+		cpuSummary.Update()
+		memory.updateSwap()
+		tcpHealth.Update()
+		netTraffic.Sample(netSelect)
+		if diskIOCounters, err := disk.IOCounters(); err == nil {
+			diskTraffic.Update(diskIOCounters, diskSelect)
+		}
+	}
+
+	// toggleSection hides or shows a section. A hidden section takes no
+	// readings, so when it's shown again its rates start from a fresh
+	// baseline and its graph starts over rather than bridging the gap.
+	toggleSection := func(s section) {
+		shown[s] = !shown[s]
+		if shown[s] {
+			resyncRates()
+		}
+		switch s {
+		case sectionCPU:
+			resetCPUData(&cpuData)
+		case sectionNetwork:
+			if shown[s] {
+				resetNetworkData(&netData)
+				startConnections()
+			} else {
+				stopConnections()
+				netProcs.Close()
+				listeners.Close()
+			}
+		case sectionDisk:
+			if shown[s] {
+				resetDiskData(&diskData)
+			} else {
+				filesystems.Close()
+				raid.Close()
+			}
+		case sectionProcesses:
+			if shown[s] {
+				collector.Request(processList.collectOptions())
+			} else {
+				// Nothing is left to show the details beside, and the
+				// busiest process named on the average gauge would go stale
+				processDetail.Close()
+				state.SetProcesses(nil)
+			}
+		}
+		if shown[s] {
+			footer.SetStatus(fmt.Sprintf("[%s shown](fg:ok)", sectionNames[s]))
+		} else {
+			footer.SetStatus(fmt.Sprintf("[%s hidden; press %d to show it again](fg:ok)", sectionNames[s], s+1))
+		}
+		layout()
+
+		// The graphs may have been split or rescaled while hidden
+		switch {
+		case s == sectionNetwork && shown[s]:
+			showNetworkGraphs(&netData, netUnit, netGraph, netOutGraph)
+		case s == sectionDisk && shown[s]:
+			showDiskGraphs(&diskData, diskGraph, diskWriteGraph)
+		}
+		redraw()
+	}
+
+	// sectionTitleAt returns the section whose title row, the top border of
+	// the widget heading it, is at pt. Sections only have titles in the full
+	// layout.
+	sectionTitleAt := func(pt image.Point) (section, bool) {
+		if mode != layoutFull {
+			return 0, false
+		}
+		headings := []struct {
+			section section
+			rect    image.Rectangle
+		}{
+			{sectionCPU, cpuDisplay.Gauges[0].Gauge.Rectangle},
+			{sectionNetwork, netStats.Rectangle},
+			{sectionDisk, diskStats.Rectangle},
+			{sectionProcesses, processList.Rectangle},
+		}
+		for _, h := range headings {
+			if shown[h.section] && pt.Y == h.rect.Min.Y && pt.In(h.rect) {
+				return h.section, true
+			}
+		}
+		return 0, false
+	}
+
+	// Main event loop. Whatever an event or tick updated is drawn at once
+	// before waiting for the next.
+	for {
+		flush()
+		select {
+		case e := <-uiEvents:
+			// Any key closes the help, which covers parts of several
+			// sections, so everything is drawn again
+			if help.Active && e.Type == ui.KeyboardEvent {
+				help.Close()
+				redraw()
+				continue
+			}
+
+			// While the kill confirmation is open it captures all key presses
+			if killPrompt.Active && e.Type == ui.KeyboardEvent {
+				if (e.ID == "y" || e.ID == "Y") && killPrompt.Tree {
+					footer.SetStatus(fmt.Sprintf("[Killing %s (PID %d) and its children...](fg:warn)", killPrompt.Name, killPrompt.PID))
+					killPrompt.ConfirmTree(killResults)
+				} else if e.ID == "y" || e.ID == "Y" {
+					if err := killPrompt.Confirm(); err != nil {
+						footer.SetStatus(fmt.Sprintf("[Failed to signal %s (PID %d): %v](fg:crit)", killPrompt.Name, killPrompt.PID, err))
+					} else {
+						// Refresh right away so the killed process disappears
+						collector.Request(processList.collectOptions())
+					}
+				} else {
+					killPrompt.Close()
+				}
+				render(processList, footer)
+				continue
+			}
+
+			// While the column menu is open it captures all key presses
+			if columnMenu.Active && e.Type == ui.KeyboardEvent {
+				switch e.ID {
+				case "<Up>":
+					columnMenu.ScrollUp()
+				case "<Down>":
+					columnMenu.ScrollDown()
+				case "<Space>", "<Enter>":
+					processList.ToggleColumn(columnMenu.SelectedColumn())
+					columnMenu.update(processList)
+					collector.Request(processList.collectOptions())
+				case "<Escape>", "<F2>", "q":
+					columnMenu.Close()
+				}
+				render(processList)
+				if columnMenu.Active {
+					render(columnMenu)
+				}
+				continue
+			}
+
+			// While the filter input is open it captures all key presses
+			if filterInput.Active && e.Type == ui.KeyboardEvent {
+				switch e.ID {
+				case "<Escape>":
+					filterInput.Close()
+					processList.SetFilter("")
+					footer.Reset()
+				case "<Enter>":
+					filterInput.Close()
+					footer.Reset()
+				default:
+					if filterInput.HandleKey(e.ID) {
+						processList.SetFilter(filterInput.Value)
+					}
+					footer.ShowInput(filterInput)
+				}
+				render(processList, footer)
+				continue
+			}
+
+			// Mouse events go by where the widgets are now, so they follow
+			// resizes and hidden sections. Overlays ignore the mouse, and
+			// clicks on a panel don't reach the section beneath it.
+			if e.Type == ui.MouseEvent {
+				mouse := e.Payload.(ui.Mouse)
+				pt := image.Pt(mouse.X, mouse.Y)
+				if mouse.Drag || help.Active || killPrompt.Active || columnMenu.Active || filterInput.Active || coveredByPanel(pt) {
+					continue
+				}
+				overList := placed[processList] && pt.In(processList.Block.Rectangle)
+				switch e.ID {
+				case "<MouseWheelUp>", "<MouseWheelDown>":
+					if overList {
+						rows := wheelRows
+						if e.ID == "<MouseWheelUp>" {
+							rows = -wheelRows
+						}
+						processList.ScrollAmount(rows)
+						render(processList)
+					}
+				case "<MouseLeft>":
+					title, onTitle := sectionTitleAt(pt)
+					switch {
+					case onTitle && title == sectionCPU:
+						// The CPU section collapses to its average gauge
+						// rather than hiding
+						cpuDisplay.Collapsed = !cpuDisplay.Collapsed
+						layout()
+						redraw()
+					case onTitle:
+						toggleSection(title)
+					case mode == layoutFull && pt.Y == memory.Gauge.Min.Y && pt.In(memory.Gauge.Rectangle):
+						footer.SetStatus("[The memory section is always shown](fg:warn)")
+						render(footer)
+					case overList:
+						if col, ok := processList.ColumnAt(pt.X, pt.Y); ok {
+							if !col.Sortable {
+								footer.SetStatus(fmt.Sprintf("[The %s column can't be sorted](fg:warn)", col.Title))
+								render(footer)
+								break
+							}
+							processList.SetSort(col.Sort)
+							render(processList)
+						} else if processList.SelectAt(pt.Y) {
+							if info, ok := processList.Selected(); ok && processDetail.Active {
+								processDetail.Open(info, processList.CPUHistory(info.PID))
+								render(processDetail)
+							}
+							render(processList)
+						}
+					}
+				}
+				continue
+			}
+
+			binding := boundKeys[e.ID]
+			action := binding.Action
+			if e.Type == ui.ResizeEvent {
+				action = actResize
+			}
+			if mode == layoutTooSmall && action != actQuit && action != actResize {
+				// Nothing else can be seen to take effect
+				continue
+			}
+			if s, ok := headingSections[binding.Section]; ok && !shown[s] {
+				footer.SetStatus(fmt.Sprintf("[%s is hidden; press %d to show it](fg:warn)", sectionNames[s], s+1))
+				render(footer)
+				continue
+			}
+			switch action {
+			case actQuit:
+				return
+			case actFilter:
+				filterInput.Open(processList.Filter)
+				footer.ShowInput(filterInput)
+				render(footer)
+			case actHelp:
+				help.Open(netSelect, image.Rect(0, 0, termWidth, termHeight))
+				render(help)
+			case actEscape:
+				if processDetail.Active {
+					processDetail.Close()
+					renderDiskSection()
+				} else if processList.Filter != "" && shown[sectionProcesses] {
+					processList.SetFilter("")
+					render(processList)
+				}
+			case actDetails:
+				if processDetail.Active {
+					processDetail.Close()
+					renderDiskSection()
+				} else if info, ok := processList.Selected(); ok && !processList.ByUser {
+					processDetail.Open(info, processList.CPUHistory(info.PID))
+					renderDiskSection()
+				}
+			case actKill:
+				if info, ok := processList.Selected(); ok {
+					if processList.ByUser {
+						footer.SetStatus("[Cannot kill a user row; press u to show individual processes](fg:crit)")
+						render(footer)
+						break
+					}
+					if info.Count > 1 {
+						footer.SetStatus("[Cannot kill a grouped row; press g to show individual processes](fg:crit)")
+						render(footer)
+						break
+					}
+					killPrompt.Open(info, e.ID == "K", e.ID == "<C-k>", processList.Block.Rectangle)
+					render(killPrompt)
+				}
+			case actCopy:
+				if info, ok := processList.Selected(); ok {
+					label, value := fmt.Sprintf("PID %d", info.PID), fmt.Sprint(info.PID)
+					if e.ID == "Y" {
+						label, value = fmt.Sprintf("command of PID %d", info.PID), info.Command
+					}
+					if err := copyToClipboard(value); err != nil {
+						// Show the value so it can at least be read off the screen
+						footer.SetStatus(fmt.Sprintf("[No clipboard available:](fg:warn) %s", value))
+					} else {
+						footer.SetStatus(fmt.Sprintf("[Copied %s](fg:ok)", label))
+					}
+					render(footer)
+				}
+			case actJump:
+				jump := processList.SelectParent
+				if e.ID == "C" {
+					jump = processList.SelectNextChild
+				}
+				if err := jump(); err != nil {
+					footer.SetStatus(fmt.Sprintf("[%v](fg:warn)", err))
+					render(footer)
+				}
+				if info, ok := processList.Selected(); ok && processDetail.Active {
+					processDetail.Open(info, processList.CPUHistory(info.PID))
+					render(processDetail)
+				}
+				render(processList)
+			case actPin:
+				processList.TogglePin()
+				render(processList)
+			case actGroup:
+				processList.ToggleGrouped()
+				render(processList)
+			case actContainers:
+				processList.ToggleContainers()
+				collector.Request(processList.collectOptions())
+			case actPause:
+				paused = !paused
+				if !paused {
+					resyncRates()
+				}
+				showPaused(header, paused)
+				render(header)
+			case actFreeze:
+				processList.ToggleFreeze()
+				render(processList)
+			case actOwner:
+				processList.ToggleOwner()
+				render(processList)
+			case actCommandMode:
+				processList.CycleCommandMode()
+				footer.SetStatus(fmt.Sprintf("[Command column: %s](fg:ok)", processList.CommandMode))
+				render(processList, footer)
+			case actUserView:
+				processList.ToggleUserView()
+				render(processList)
+			case actToggleCPU:
+				// Cycle through expanded, collapsed to the average gauge, and
+				// hidden; each moves every section below
+				switch {
+				case !shown[sectionCPU]:
+					cpuDisplay.Collapsed = false
+					toggleSection(sectionCPU)
+				case cpuDisplay.Collapsed:
+					toggleSection(sectionCPU)
+				default:
+					cpuDisplay.Collapsed = true
+					layout()
+					redraw()
+				}
+			case actToggleNetwork:
+				toggleSection(sectionNetwork)
+			case actToggleDisk:
+				toggleSection(sectionDisk)
+			case actToggleProcesses:
+				toggleSection(sectionProcesses)
+			case actCoreView:
+				// The heatmap takes a different height than the cells
+				cpuDisplay.View = (cpuDisplay.View + 1) % coreViewCount
+				layout()
+				redraw()
+			case actReadSensors:
+				readTemperature(temperatures)
+			case actIdleInterfaces:
+				ifaceTable.ShowIdle = !ifaceTable.ShowIdle
+				ifaceTable.Update(netTraffic.Interfaces, netTraffic.Active)
+				if ifaceTable.Visible {
+					render(ifaceTable)
+				}
+			case actNetUnits:
+				// Rescale the history so the graph keeps its shape
+				prevUnit := netUnit
+				netUnit = (netUnit + 1) % netUnitCount
+				ifaceTable.Unit = netUnit
+				netProcs.SetUnit(netUnit)
+				rescaleNetworkData(&netData, netUnit.Graph(1)/prevUnit.Graph(1))
+				footer.SetStatus(fmt.Sprintf("[Network rates in %s](fg:ok)", netUnitNames[netUnit]))
+				render(footer)
+			case actNetProcs:
+				if netProcs.Active {
+					netProcs.Close()
+				} else {
+					closeDiskPanels()
+					netProcs.Open()
+				}
+				renderDiskSection()
+			case actListeners:
+				if listeners.Active {
+					listeners.Close()
+				} else {
+					closeDiskPanels()
+					listeners.Open()
+				}
+				renderDiskSection()
+			case actFilesystems:
+				if filesystems.Active {
+					filesystems.Close()
+				} else {
+					closeDiskPanels()
+					filesystems.Open()
+				}
+				renderDiskSection()
+			case actRAID:
+				switch {
+				case raid.Active:
+					raid.Close()
+				case len(raid.Arrays) == 0:
+					footer.SetStatus("[No software RAID arrays](fg:warn)")
+					render(footer)
+				default:
+					closeDiskPanels()
+					raid.Open()
+				}
+				renderDiskSection()
+			case actNetLogScale:
+				netData.LogScale = !netData.LogScale
+				if shown[sectionNetwork] {
+					showNetworkGraphs(&netData, netUnit, netGraph, netOutGraph)
+				}
+			case actDiskLogScale:
+				diskData.LogScale = !diskData.LogScale
+				if shown[sectionDisk] {
+					showDiskGraphs(&diskData, diskGraph, diskWriteGraph)
+					renderDiskSection()
+				}
+			case actFreeSpace:
+				freeSpace.Active = !freeSpace.Active
+				renderDiskSection()
+			case actNextMount:
+				freeSpace.Cycle()
+				footer.SetStatus(fmt.Sprintf("[Free space graph: %s](fg:ok)", freeSpace.Mount))
+				render(footer)
+				if freeSpace.Active {
+					renderDiskSection()
+				}
+			case actSplitGraphs:
+				// Each graph takes half the height, so every section moves
+				netData.Split = !netData.Split
+				diskData.Split = netData.Split
+				layout()
+				if shown[sectionNetwork] {
+					showNetworkGraphs(&netData, netUnit, netGraph, netOutGraph)
+				}
+				if shown[sectionDisk] {
+					showDiskGraphs(&diskData, diskGraph, diskWriteGraph)
+				}
+				redraw()
+			case actNextDisk:
+				// Rates of different devices don't belong on one scale
+				diskSelect.Cycle()
+				diskData.Device = diskSelect.Label()
+				resetDiskData(&diskData)
+				showDiskGraphs(&diskData, diskGraph, diskWriteGraph)
+				renderDiskSection()
+			case actResetSession:
+				// Shown with the next network update
+				netTraffic.ResetSession()
+				footer.SetStatus("[Session network totals reset](fg:ok)")
+				render(footer)
+			case actNextInterface:
+				// Rates of different interfaces don't belong on one scale
+				netSelect.Cycle()
+				netStats.Title = netSelect.Title(links)
+				resetNetworkData(&netData)
+				render(netStats)
+				showNetworkGraphs(&netData, netUnit, netGraph, netOutGraph)
+			case actKernel:
+				processList.ToggleKernel()
+				collector.Request(processList.collectOptions())
+			case actSortCPU:
+				processList.SetSort(SortByCPU)
+				render(processList)
+			case actSortMemory:
+				processList.SetSort(SortByMemory)
+				render(processList)
+			case actSortPID:
+				processList.SetSort(SortByPID)
+				render(processList)
+			case actSortName:
+				processList.SetSort(SortByName)
+				render(processList)
+			case actSortThreads:
+				processList.SetSort(SortByThreads)
+				render(processList)
+			case actSortTime:
+				processList.SetSort(SortByTime)
+				render(processList)
+			case actSortAge:
+				processList.SetSort(SortByAge)
+				render(processList)
+			case actColumnMenu:
+				columnMenu.Open(processList, processList.Block.Rectangle)
+				render(columnMenu)
+			case actIOColumns:
+				processList.ToggleIO()
+				collector.Request(processList.collectOptions())
+				render(processList)
+			case actMemoryUnit:
+				processList.ToggleMemoryUnit()
+				render(processList)
+			case actMemoryView:
+				processList.ToggleMemoryView()
+				collector.Request(processList.collectOptions())
+				render(processList)
+			case actSortFDs:
+				if processList.HasColumn("fds") {
+					processList.SetSort(SortByFDs)
+					render(processList)
+				}
+			case actSortOOM:
+				if processList.HasColumn("oom") {
+					processList.SetSort(SortByOOM)
+					render(processList)
+				}
+			case actSortIO:
+				if processList.ShowIO() {
+					key := SortByRead
+					if e.ID == "w" {
+						key = SortByWrite
+					}
+					processList.SetSort(key)
+					render(processList)
+				}
+			case actUp:
+				processList.ScrollUp()
+				render(processList)
+			case actDown:
+				processList.ScrollDown()
+				render(processList)
+			case actPageUp:
+				processList.ScrollPageUp()
+				render(processList)
+			case actPageDown:
+				processList.ScrollPageDown()
+				render(processList)
+			case actTop:
+				processList.ScrollTop()
+				render(processList)
+			case actBottom:
+				processList.ScrollBottom()
+				render(processList)
+			case actResize:
+				resized = e.Payload.(ui.Resize)
+				resizeSettled = time.After(resizeSettle)
+			}
+
+		case <-resizeSettled:
+			resizeSettled = nil
+			termWidth, termHeight = resized.Width, resized.Height
+
+			// Trim or pad every history to the new width, keeping the
+			// newest samples, then lay out and plot them
+			dataPointCount := historyPoints(termWidth)
+			memory.Resize(dataPointCount)
+			cpuData.AvgData = resizeHistory(cpuData.AvgData, dataPointCount)
+			cpuData.PeakData = resizeHistory(cpuData.PeakData, dataPointCount)
+			cpuGraph.Data[0] = cpuData.AvgData
+			cpuGraph.Data[1] = cpuData.PeakData
+			netData.RxData = resizeHistory(netData.RxData, dataPointCount)
+			netData.TxData = resizeHistory(netData.TxData, dataPointCount)
+			diskData.ReadData = resizeHistory(diskData.ReadData, dataPointCount)
+			diskData.WriteData = resizeHistory(diskData.WriteData, dataPointCount)
+
+			layout()
+			plotNetworkData(&netData, netGraph, netOutGraph)
+			plotDiskData(&diskData, diskGraph, diskWriteGraph)
+			updateHeader(header, cpuID, mounts, battery, termWidth)
+
+			// Complete redraw is necessary on resize
+			redraw()
+
+		case <-animationTicker.C:
+			// Animate CPU gauges toward target values, redrawing only while
+			// they are still moving
+			if shown[sectionCPU] && animateCPUGauges(cpuDisplay.Gauges, animationSpeed, cpuDisplay.Thresholds) {
+				cpuDisplay.Render()
+			}
+
+		case <-dataTicker.C:
+			if paused {
+				if footer.expireStatus() {
+					render(footer)
+				}
+				break
+			}
+
+			if shown[sectionCPU] {
+				// Update CPU gauges target values
+				if cores := cpuSummary.Cores; updateCPUTargets(cpuDisplay, state) {
+					// The number of cores changed, so the CPU section's
+					// height may have too
+					cpuSummary.Cores = len(cpuDisplay.Gauges) - 1
+					footer.SetStatus(fmt.Sprintf("[CPU count changed from %d to %d](fg:warn)", cores, cpuSummary.Cores))
+					layout()
+					redraw()
+				}
+				cpuTimes.Update()
+				cpuDisplay.Gauges[0].Detail = cpuTimes.String()
+				cpuSummary.Update()
+				render(cpuSummary)
+
+				// Redraw for the new titles, labels, and sparklines
+				cpuDisplay.Render()
+
+				// Shift CPU history data and add the average and busiest core
+				avgPercent, peakPercent := cpuDisplay.Gauges[0].TargetPercent, 0.0
+				for _, g := range cpuDisplay.Gauges[1:] {
+					peakPercent = max(peakPercent, g.TargetPercent)
+				}
+				updateCPUGraph(&cpuData, avgPercent, peakPercent, cpuGraph)
+			}
+
+			// Update memory usage
+			if memory.Update() {
+				// The hugepage line came or went, moving every section below
+				layout()
+				redraw()
+			} else {
+				render(memory.Drawables()...)
+			}
+
+			// Refresh the header's memory, disk, and battery figures
+			if time.Since(lastHeaderUpdate) >= headerRefreshInterval {
+				oldText := header.Text
+				battery.Update()
+				updateHeader(header, cpuID, mounts, battery, termWidth)
+				lastHeaderUpdate = time.Now()
+
+				// Only redraw if the text changed
+				if header.Text != oldText {
+					render(header)
+				}
+
+				// RAID arrays are checked as often, for the disk section
+				if shown[sectionDisk] {
+					raid.Update()
+					if len(raid.Arrays) == 0 {
+						raid.Close()
+					}
+					oldTitle := diskStats.Title
+					showRAIDAlert()
+					if diskStats.Title != oldTitle || raid.Active {
+						renderDiskSection()
+					}
+				}
+			}
+
+			// Update network information, which isn't collected while the
+			// section is hidden
+			if shown[sectionNetwork] {
+				if traffic, err := netTraffic.Sample(netSelect); err == nil {
+					compactNet.Text = compactNetText(traffic, netUnit)
+					render(compactNet)
+
+					newText := netTraffic.Text(traffic, netUnit, tcpConns.Text())
+					tcpHealth.Update()
+					if tcpHealth.Available {
+						newText += "\n" + tcpHealth.Text()
+					}
+
+					// Only update if the text or the link details changed
+					newTitle := netSelect.Title(links)
+					if newText != netStats.Text || newTitle != netStats.Title {
+						netStats.Text = newText
+						netStats.Title = newTitle
+						render(netStats)
+					}
+
+					// Shift network history data and add new values
+					updateNetworkGraph(&netData, traffic.RxRate, traffic.TxRate, netUnit, netGraph, netOutGraph)
+
+					ifaceTable.Update(netTraffic.Interfaces, netTraffic.Active)
+					if ifaceTable.Visible {
+						render(ifaceTable)
+					}
+				}
+			}
+
+			// Update disk I/O information, likewise
+			if shown[sectionDisk] {
+				if diskIOCounters, err := disk.IOCounters(); err == nil {
+					diskLines := diskTraffic.Lines()
+					diskTotal := diskTraffic.Update(diskIOCounters, diskSelect)
+					compactDisk.Text = compactDiskText(diskTotal)
+					render(compactDisk)
+
+					// Only update if the text changed
+					if diskText := diskTraffic.Text(); diskText != diskStats.Text {
+						diskStats.Text = diskText
+						render(diskStats)
+					}
+
+					// Update disk I/O graph, naming the busiest device in its title
+					diskData.Busiest = ""
+					if busiest, ok := diskTraffic.Busiest(); ok {
+						diskData.Busiest = fmt.Sprintf("%s %.0f%%", busiest.Name, busiest.Busy)
+					}
+					updateDiskGraph(&diskData, diskTotal, diskGraph, diskWriteGraph)
+
+					// A device coming or going can change the paragraph's height
+					if diskTraffic.Lines() != diskLines {
+						layout()
+						redraw()
+					}
+				}
+			}
+
+			// Ask for a fresh process snapshot every --proc-every readings;
+			// skipped if the last pass is still running
+			readings++
+			if readings%*procEvery == 0 && shown[sectionProcesses] {
+				collector.Request(processList.collectOptions())
+			}
+
+			if freeSpace.Active && shown[sectionDisk] {
+				render(freeSpace)
+			}
+			if netProcs.Active {
+				render(netProcs)
+			}
+			if listeners.Active {
+				render(listeners)
+			}
+			if filesystems.Active {
+				render(filesystems)
+			}
+			if raid.Active {
+				render(raid)
+			}
+			if processDetail.Active {
+				processDetail.update(processList.CPUHistory(processDetail.PID))
+				render(processDetail)
+			}
+
+			if footer.expireStatus() {
+				render(footer)
+			}
+
+		case tcpConns = <-connSummaries:
+			// Shown with the next network update
+
+		case result := <-pingResults:
+			if paused {
+				break
+			}
+			// Probing carries on while the network section is hidden, so
+			// the loss figures cover the whole session
+			pingPanel.Add(result)
+			if shown[sectionNetwork] {
+				render(pingPanel)
+			}
+
+		case snap := <-netProcs.Snapshots():
+			if paused {
+				break
+			}
+			netProcs.Update(snap)
+			if !processDetail.Active {
+				render(netProcs)
+			}
+
+		case snap := <-listeners.Snapshots():
+			if paused {
+				break
+			}
+			listeners.Update(snap)
+			if !processDetail.Active {
+				render(listeners)
+			}
+
+		case snap := <-filesystems.Snapshots():
+			if paused {
+				break
+			}
+			filesystems.Update(snap)
+			freeSpace.Add(snap)
+			if shown[sectionDisk] && (filesystems.Active || freeSpace.Active) {
+				renderDiskSection()
+			}
+
+		case reading := <-temperatures:
+			cpuSummary.SetTemperature(reading)
+			if shown[sectionCPU] {
+				render(cpuSummary)
+			}
+
+		case result := <-killResults:
+			if result.Failed > 0 {
+				footer.SetStatus(fmt.Sprintf("[Killed tree of %s (PID %d): signalled %d, %d failed](fg:crit)", result.Name, result.PID, result.Signalled, result.Failed))
+			} else {
+				footer.SetStatus(fmt.Sprintf("[Killed tree of %s (PID %d): signalled %d](fg:ok)", result.Name, result.PID, result.Signalled))
+			}
+			render(footer)
+			// Refresh right away so the killed processes disappear
+			if shown[sectionProcesses] {
+				collector.Request(processList.collectOptions())
+			}
+
+		case snap := <-collector.Snapshots:
+			// Swap in the latest process snapshot, unless the process list
+			// was hidden since it was asked for
+			if !shown[sectionProcesses] {
+				break
+			}
+			processList.SetSnapshot(snap)
+			state.SetProcesses(snap.Processes)
+			render(processList)
+			if killPrompt.Active {
+				render(killPrompt)
+			}
+			if columnMenu.Active {
+				render(columnMenu)
+			}
+		}
+
+		// Every update draws over the help, so it goes back on top
+		if help.Active {
+			render(help)
+		}
+	}
+}
+
+// currentUser returns the UID and name of the user running sysgomon
+func currentUser() (int32, string) {
+	uid := int32(os.Getuid())
+	if u, err := user.Current(); err == nil {
+		return uid, u.Username
+	}
+	return uid, strconv.Itoa(int(uid))
+}
+
+// lookupUser resolves a user name or numeric UID to a UID and display name
+func lookupUser(spec string) (int32, string, error) {
+	if id, err := strconv.ParseInt(spec, 10, 32); err == nil {
+		if u, err := user.LookupId(spec); err == nil {
+			return int32(id), u.Username, nil
+		}
+		return int32(id), spec, nil
+	}
+	u, err := user.Lookup(spec)
+	if err != nil {
+		return 0, "", err
+	}
+	id, err := strconv.ParseInt(u.Uid, 10, 32)
+	if err != nil {
+		return 0, "", fmt.Errorf("user %s has no numeric UID", spec)
+	}
+	return int32(id), u.Username, nil
+}
+
+func createCPUGauges() []CPUGauge {
+	// Get number of CPU cores
+	cpuCount, err := cpu.Counts(true)
+	if err != nil {
+		log.Printf("Error getting CPU count: %v", err)
+		cpuCount = 1
+	}
+
+	// Create individual gauges for each CPU core, plus one for the average
+	gauges := make([]CPUGauge, cpuCount+1)
+	for i := range gauges {
+		gauges[i] = newCPUGauge(fmt.Sprintf("CPU %d", i))
+	}
 
-					netData.RxData = newRxData
-					netData.TxData = newTxData
+	// First gauge is for average CPU
+	gauges[0] = newCPUGauge("Avg CPU")
 
-					netGraph.Data[0] = netData.RxData
-					netGraph.Data[1] = netData.TxData
-				}
+	return gauges
+}
 
-				// Update disk graph data points if needed
-				if dataPointCount > len(diskData.ReadData) {
-					newReadData := make([]float64, dataPointCount)
-					newWriteData := make([]float64, dataPointCount)
+func newCPUGauge(label string) CPUGauge {
+	g := CPUGauge{
+		Gauge:   widgets.NewGauge(),
+		Spark:   widgets.NewParagraph(),
+		History: newCPUHistory(coreHistoryLength),
+		Label:   label,
+	}
+	g.Gauge.Title = label
+	g.Gauge.BarColor = theme.Ok
+	g.Gauge.BorderStyle.Fg = theme.Frame
+	g.Gauge.TitleStyle.Fg = theme.Label
+	g.Spark.Title = label
+	g.Spark.BorderStyle.Fg = theme.Frame
+	g.Spark.TitleStyle.Fg = theme.Label
+	return g
+}
 
-					// Copy existing data to preserve history
-					copy(newReadData[dataPointCount-len(diskData.ReadData):], diskData.ReadData)
-					copy(newWriteData[dataPointCount-len(diskData.WriteData):], diskData.WriteData)
+// CoreView selects how the CPU display draws each core
+type CoreView int
 
-					diskData.ReadData = newReadData
-					diskData.WriteData = newWriteData
+const (
+	CoreGauges     CoreView = iota // A gauge per core
+	CoreSparklines                 // A sparkline of recent utilization per core
+	CoreHeatmap                    // One colored block per core
+	coreViewCount
+)
 
-					diskGraph.Data[0] = diskData.ReadData
-					diskGraph.Data[1] = diskData.WriteData
-				}
+// coreViewNames are the --cpu-view values, indexed by CoreView
+var coreViewNames = []string{"gauges", "sparklines", "heatmap"}
 
-				diskStats.SetRect(0, netGraph.Block.Rectangle.Max.Y, termWidth, netGraph.Block.Rectangle.Max.Y+4)
-				diskGraph.SetRect(0, diskStats.Block.Rectangle.Max.Y, termWidth, diskStats.Block.Rectangle.Max.Y+9)
+// parseCoreView returns the CoreView called name
+func parseCoreView(name string) (CoreView, error) {
+	for i, n := range coreViewNames {
+		if strings.EqualFold(name, n) {
+			return CoreView(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown view %q (valid views: %s)", name, strings.Join(coreViewNames, ", "))
+}
 
-				// Update process list position
-				processList.SetRect(0, diskGraph.Block.Rectangle.Max.Y, termWidth, termHeight-1)
+// CPUDisplay is the CPU section below the summary line: the average gauge
+// and a gauge or sparkline per core, or a dense grid of one-line bars when
+// there are too many cores for gauges to fit
+type CPUDisplay struct {
+	Gauges     []CPUGauge           // Average first, then one per core
+	View       CoreView             // How each core is drawn
+	Collapsed  bool                 // Whether only the average gauge is shown
+	Thresholds usageThresholds      // Utilization at which cores turn yellow and red
+	Grid       *widgets.Paragraph   // Dense per-core bars used in compact mode
+	Heat       *Heatmap             // One colored block per core in the heatmap view
+	Groups     []coreGroup          // Topology groups shown under headings, nil for a flat layout
+	groupLines []*widgets.Paragraph // Heading with the group's average, one per group
+	compact    bool                 // Whether the last layout chose the grid
+	gridCols   int                  // Columns of the grid in compact mode
+}
 
-				footer.SetRect(0, termHeight-1, termWidth, termHeight)
+func createCPUDisplay() *CPUDisplay {
+	d := &CPUDisplay{
+		Gauges:     createCPUGauges(),
+		Thresholds: defaultCPUThresholds,
+		Grid:       widgets.NewParagraph(),
+		Heat:       NewHeatmap(),
+	}
+	d.Heat.Title = "Cores"
+	d.Heat.BorderStyle.Fg = theme.Frame
+	d.Heat.TitleStyle.Fg = theme.Label
+	d.Groups = cpuTopology(len(d.Gauges) - 1)
+	for range d.Groups {
+		line := widgets.NewParagraph()
+		line.Border = false
+		d.groupLines = append(d.groupLines, line)
+	}
+	d.Grid.Title = "Cores"
+	d.Grid.BorderStyle.Fg = theme.Frame
+	d.Grid.TitleStyle.Fg = theme.Label
+	return d
+}
 
-				// Complete redraw is necessary on resize
-				ui.Clear()
-				ui.Render(header, cpuTitle)
-				for _, gauge := range cpuGauges {
-					ui.Render(gauge.Gauge)
-				}
-				ui.Render(netStats, netGraph, diskStats, diskGraph, processList, footer)
+// Layout places the average gauge across the full width at y=4 and, unless
+// collapsed, the cores below it, and returns the y the CPU section ends at.
+// Cores are drawn as 3-row cells in two columns (each core's gauge and
+// sparkline share the same cell) when those fit above maxBottom; otherwise
+// they switch to the compact grid so the sections below keep their room.
+// The heatmap view always packs the cores into as few rows as possible.
+func (d *CPUDisplay) Layout(width, maxBottom int) int {
+	gauges := d.Gauges
+	gauges[0].Gauge.SetRect(0, 4, width, 7) // Start at y=4
+	if d.Collapsed {
+		return 7
+	}
+	cpuCount := len(gauges) - 1
+	if d.View == CoreHeatmap {
+		bottom := 7 + heatmapRows(cpuCount, width) + 2 // Plus the border
+		d.Heat.SetRect(0, 7, width, bottom)
+		return bottom
+	}
+	var bottom int
+	if d.grouped() {
+		// Each group gets a heading line, then its cores in two columns
+		bottom = 7
+		for gi, group := range d.Groups {
+			d.groupLines[gi].SetRect(0, bottom, width, bottom+1)
+			bottom++
+			for j, core := range group.Cores {
+				x1, y1, x2, y2 := cpuCoreCell(j, len(group.Cores), width, bottom)
+				gauges[core+1].Gauge.SetRect(x1, y1, x2, y2)
+				gauges[core+1].Spark.SetRect(x1, y1, x2, y2)
 			}
+			bottom += (len(group.Cores) + 1) / 2 * 3
+		}
+	} else {
+		for i := 0; i < cpuCount; i++ {
+			x1, y1, x2, y2 := cpuCoreCell(i, cpuCount, width, 7)
+			gauges[i+1].Gauge.SetRect(x1, y1, x2, y2)
+			gauges[i+1].Spark.SetRect(x1, y1, x2, y2)
+		}
 
-		case <-ticker:
-			// Update CPU gauges target values
-			updateCPUTargets(cpuGauges)
+		// Calculate total height based on the number of rows needed
+		rowsPerColumn := (cpuCount + 1) / 2 // Round up for odd number of cores
+		bottom = 7 + rowsPerColumn*3        // Start from y=7
+	}
+	d.compact = bottom > maxBottom
+	if !d.compact {
+		return bottom
+	}
 
-			// Animate CPU gauges toward target values
-			animateCPUGauges(cpuGauges, animationSpeed)
+	// Use the fewest grid columns whose rows fit in the space left, as long
+	// as each cell stays readable
+	rowsAvailable := maxBottom - 7 - 2 // Less the grid's border
+	if rowsAvailable < 1 {
+		rowsAvailable = 1
+	}
+	maxCols := (width - 2) / minGridCellWidth
+	if maxCols < 1 {
+		maxCols = 1
+	}
+	d.gridCols = maxCols
+	for cols := 1; cols <= maxCols; cols++ {
+		if (cpuCount+cols-1)/cols <= rowsAvailable {
+			d.gridCols = cols
+			break
+		}
+	}
+	rows := (cpuCount + d.gridCols - 1) / d.gridCols
+	d.Grid.SetRect(0, 7, width, 7+rows+2)
+	return 7 + rows + 2
+}
 
-			// Update network information
-			now := time.Now()
-			if netIOCounters, err := net.IOCounters(false); err == nil {
-				duration := now.Sub(lastNetworkUpdate).Seconds()
-				rxBytesPerSec := float64(netIOCounters[0].BytesRecv-prevNetIOStats.BytesRecv) / duration
-				txBytesPerSec := float64(netIOCounters[0].BytesSent-prevNetIOStats.BytesSent) / duration
+// MinHeight returns the fewest rows Layout can fit the cores into below the
+// average gauge at the given width
+func (d *CPUDisplay) MinHeight(width int) int {
+	cpuCount := len(d.Gauges) - 1
+	switch {
+	case d.Collapsed:
+		return 0
+	case d.View == CoreHeatmap:
+		return heatmapRows(cpuCount, width) + 2
+	}
+	cols := (width - 2) / minGridCellWidth
+	if cols < 1 {
+		cols = 1
+	}
+	return min((cpuCount+1)/2*3, (cpuCount+cols-1)/cols+2)
+}
 
-				rxMbps := rxBytesPerSec * 8 / 1000000 // Convert bytes/sec to Mbps
-				txMbps := txBytesPerSec * 8 / 1000000 // Convert bytes/sec to Mbps
+// Drawables returns every widget the display may draw, for the layout to
+// mark as placed; Render picks those the current view uses
+func (d *CPUDisplay) Drawables() []ui.Drawable {
+	items := []ui.Drawable{d.Grid, d.Heat}
+	for _, g := range d.Gauges {
+		items = append(items, g.Gauge, g.Spark)
+	}
+	for _, line := range d.groupLines {
+		items = append(items, line)
+	}
+	return items
+}
 
-				// Update network text display
-				newText := fmt.Sprintf(
-					"[In:  ](fg:green) %8.2f Mbps  [Out: ](fg:blue) %8.2f Mbps  [Total In: ](fg:cyan) %s  [Total Out:](fg:cyan) %s",
-					rxMbps,
-					txMbps,
-					formatBytes(netIOCounters[0].BytesRecv),
-					formatBytes(netIOCounters[0].BytesSent),
-				)
+// grouped reports whether cores are shown under topology headings, which
+// stops once the core count no longer matches the topology read at startup
+func (d *CPUDisplay) grouped() bool {
+	return d.Groups != nil && coversCores(d.Groups, len(d.Gauges)-1)
+}
 
-				// Only update if the text changed
-				if newText != netStats.Text {
-					netStats.Text = newText
-					ui.Render(netStats)
-				}
+// groupText renders a group's heading with the average of its cores, e.g.
+// "P-cores  avg 34%"
+func (d *CPUDisplay) groupText(group coreGroup) string {
+	var total float64
+	for _, core := range group.Cores {
+		total += d.Gauges[core+1].CurrentPercent
+	}
+	avg := total / float64(len(group.Cores))
+	return fmt.Sprintf("[%s](fg:text,mod:bold)  avg [%.0f%%](fg:%s)", group.Name, avg, d.Thresholds.Color(avg))
+}
 
-				// Shift network history data and add new values
-				updateNetworkGraph(&netData, rxMbps, txMbps, netGraph)
+// SetTargets sets the average gauge to avg and each core's gauge to its
+// entry in percentages. When percentages doesn't hold one entry per core, as
+// happens right after waking from sleep or when CPUs go on or offline, the
+// core gauges are resized to match rather than skipping cores, and
+// SetTargets returns true. An empty percentages leaves the cores alone.
+func (d *CPUDisplay) SetTargets(avg float64, percentages []float64) bool {
+	d.Gauges[0].TargetPercent = avg
 
-				prevNetIOStats = netIOCounters[0]
-				lastNetworkUpdate = now
-			}
+	resized := false
+	if len(percentages) > 0 && len(percentages) != len(d.Gauges)-1 {
+		d.resizeCores(len(percentages))
+		resized = true
+	}
 
-			// Update disk I/O information
-			if diskIOCounters, err := disk.IOCounters(); err == nil {
-				duration := now.Sub(lastDiskUpdate).Seconds()
-				diskText := ""
+	// Update individual CPU gauge targets and histories
+	for i, percent := range percentages {
+		d.Gauges[i+1].TargetPercent = percent
+		d.Gauges[i+1].History.Add(percent)
+	}
+	return resized
+}
 
-				// Calculate total read and write speeds across all disks
-				var totalReadMBps, totalWriteMBps float64
-				for name, stat := range diskIOCounters {
-					if prev, ok := prevDiskIOStats[name]; ok {
-						readBytesPerSec := float64(stat.ReadBytes-prev.ReadBytes) / duration / 1024 / 1024    // MB/s
-						writeBytesPerSec := float64(stat.WriteBytes-prev.WriteBytes) / duration / 1024 / 1024 // MB/s
+// resizeCores drops gauges from the end or adds new ones until there are
+// count core gauges, keeping the history of the ones that remain
+func (d *CPUDisplay) resizeCores(count int) {
+	if count < len(d.Gauges)-1 {
+		d.Gauges = d.Gauges[:count+1]
+		return
+	}
+	for i := len(d.Gauges); i <= count; i++ {
+		d.Gauges = append(d.Gauges, newCPUGauge(fmt.Sprintf("CPU %d", i)))
+	}
+}
 
-						totalReadMBps += readBytesPerSec
-						totalWriteMBps += writeBytesPerSec
+// minGridCellWidth is the narrowest a core's bar may get in compact mode
+const minGridCellWidth = 16
 
-						if len(diskText) > 0 {
-							diskText += "\n"
-						}
-						diskText += fmt.Sprintf(
-							"[%s](fg:yellow) Read: [%.2f MB/s](fg:green) Write: [%.2f MB/s](fg:red)",
-							name, readBytesPerSec, writeBytesPerSec,
-						)
-					}
-					prevDiskIOStats[name] = stat
-				}
+// gridText builds the compact grid, one "12 ||||     45%" bar per core,
+// numbered down each column like the gauges
+func (d *CPUDisplay) gridText() string {
+	cores := d.Gauges[1:]
+	rows := (len(cores) + d.gridCols - 1) / d.gridCols
+	cellWidth := d.Grid.Inner.Dx() / d.gridCols
+	barWidth := cellWidth - 10 // Core number, percentage, and spacing
+	if barWidth < 1 {
+		barWidth = 1
+	}
 
-				// Only update if the text changed
-				if diskText != diskStats.Text {
-					diskStats.Text = diskText
-					ui.Render(diskStats)
-				}
+	lines := make([]string, rows)
+	for r := range lines {
+		var sb strings.Builder
+		for c := 0; c < d.gridCols; c++ {
+			i := c*rows + r
+			if i >= len(cores) {
+				break
+			}
+			percent := cores[i].CurrentPercent
+			filled := int(percent / 100 * float64(barWidth))
+			if filled > barWidth {
+				filled = barWidth
+			}
+			bar := strings.Repeat("|", filled) + strings.Repeat(" ", barWidth-filled)
+			fmt.Fprintf(&sb, "%3d [%s](fg:%s) %3.0f%% ", i+1, bar, d.Thresholds.Color(percent), percent)
+		}
+		lines[r] = sb.String()
+	}
+	return strings.Join(lines, "\n")
+}
 
-				// Update disk I/O graph
-				updateDiskGraph(&diskData, totalReadMBps, totalWriteMBps, diskGraph)
+// usageThresholds are the utilization percentages at which a reading turns
+// yellow and red
+type usageThresholds struct {
+	Warn float64
+	Crit float64
+}
 
-				lastDiskUpdate = now
-			}
+// defaultCPUThresholds apply unless --cpu-warn or --cpu-crit say otherwise
+var defaultCPUThresholds = usageThresholds{Warn: 50, Crit: 80}
 
-			// Update process list
-			processList.update()
-			ui.Render(processList)
-		}
+// Validate reports thresholds outside 0-100 or a warning level that isn't
+// below the critical one
+func (t usageThresholds) Validate() error {
+	if t.Warn < 0 || t.Crit > 100 {
+		return fmt.Errorf("thresholds must be between 0 and 100")
+	}
+	if t.Warn >= t.Crit {
+		return fmt.Errorf("warning threshold %g must be below critical threshold %g", t.Warn, t.Crit)
 	}
+	return nil
 }
 
-func createCPUGauges(width int) (*widgets.Paragraph, []CPUGauge, int) {
-	// Get number of CPU cores
-	cpuCount, err := cpu.Counts(true)
-	if err != nil {
-		log.Printf("Error getting CPU count: %v", err)
-		cpuCount = 1
+// Color returns the theme role name for a utilization percentage, for
+// markup and gauge bars
+func (t usageThresholds) Color(percent float64) string {
+	switch {
+	case percent >= t.Crit:
+		return "crit"
+	case percent >= t.Warn:
+		return "warn"
 	}
+	return "ok"
+}
 
-	// Create title paragraph
-	cpuTitle := widgets.NewParagraph()
-	cpuTitle.Text = fmt.Sprintf("[CPU Utilization (%d cores)](fg:white,mod:bold)", cpuCount)
-	cpuTitle.Border = false
-	cpuTitle.SetRect(0, 3, width, 4) // Start at y=3 (after header)
+// minInterval is the shortest --interval allowed. Shorter ones would spend
+// more time collecting than the readings are worth.
+const minInterval = 50 * time.Millisecond
 
-	// Create individual gauges for each CPU core
-	gauges := make([]CPUGauge, cpuCount+1) // +1 for the average
+// animationInterval is how often the CPU gauges step toward their targets,
+// fast enough for smooth motion between data updates
+const animationInterval = 50 * time.Millisecond
 
-	// First gauge is for average CPU
-	gauges[0] = CPUGauge{
-		Gauge:          widgets.NewGauge(),
-		CurrentPercent: 0,
-		TargetPercent:  0,
-	}
-	gauges[0].Gauge.Title = "Avg CPU"
-	gauges[0].Gauge.SetRect(0, 4, width, 7) // Start at y=4
-	gauges[0].Gauge.BarColor = ui.ColorGreen
-	gauges[0].Gauge.BorderStyle.Fg = ui.ColorBlue
-	gauges[0].Gauge.TitleStyle.Fg = ui.ColorCyan
+// resizeSettle is how long the terminal must stay one size before the
+// display is laid out for it, so dragging a window edge, which sends a
+// burst of resize events, rebuilds it once rather than for every event
+const resizeSettle = 100 * time.Millisecond
 
-	// Calculate the width for each column
-	columnWidth := width / 2
+// headerRefreshInterval is how often the header's memory and disk figures
+// are re-read, as they rarely change quickly enough to need every data tick
+const headerRefreshInterval = 2 * time.Second
 
-	// Create a gauge for each CPU core
-	for i := 0; i < cpuCount; i++ {
-		gauges[i+1] = CPUGauge{
-			Gauge:          widgets.NewGauge(),
-			CurrentPercent: 0,
-			TargetPercent:  0,
-		}
-		gauges[i+1].Gauge.Title = fmt.Sprintf("CPU %d", i+1)
+// Heights of the sections below the CPU display, which it must leave room
+// for besides the memory section: the CPU history graph, network and disk
+// stats with their graphs, a minimal process list, and the footer
+const (
+	cpuGraphHeight       = 7
+	netStatsHeight       = 5 // Without the TCP health line
+	diskStatsHeight      = 4 // With up to two devices
+	sectionGraphHeight   = 9 // Network and disk graphs
+	minProcessListHeight = 8
+	footerHeight         = 1
+)
 
-		// Determine which column this CPU belongs to
-		isLeftColumn := i < cpuCount/2
+// section is a part of the display that the number keys hide and show.
+// Memory, between the CPU and network sections, is always shown.
+type section int
 
-		// Calculate x position based on column
-		xStart := 0
-		if !isLeftColumn {
-			xStart = columnWidth
-		}
+const (
+	sectionCPU section = iota
+	sectionNetwork
+	sectionDisk
+	sectionProcesses
+	sectionCount
+)
 
-		// Calculate y position based on position within column
-		yOffset := i
-		if !isLeftColumn {
-			yOffset = i - cpuCount/2
-		}
+// sectionNames name each section in footer messages, indexed by section
+var sectionNames = []string{"CPU section", "Network section", "Disk section", "Process list"}
 
-		// Ensure the right column gauges extend to the full width
-		xEnd := xStart + columnWidth
-		if !isLeftColumn {
-			xEnd = width // Make right column extend to full width
+// growGraphs shares spare rows out among the shown graphs' heights, the
+// first ones taking any remainder
+func growGraphs(spare int, heights ...*int) {
+	if spare <= 0 || len(heights) == 0 {
+		return
+	}
+	for i, h := range heights {
+		*h += spare / len(heights)
+		if i < spare%len(heights) {
+			*h++
 		}
+	}
+}
+
+// cpuCoreCell returns the rectangle of core i out of cpuCount in the
+// two-column layout starting at row top
+func cpuCoreCell(i, cpuCount, width, top int) (x1, y1, x2, y2 int) {
+	// Calculate the width for each column
+	columnWidth := width / 2
+
+	// Determine which column this CPU belongs to
+	isLeftColumn := i < cpuCount/2
+
+	// Calculate x position based on column
+	xStart := 0
+	if !isLeftColumn {
+		xStart = columnWidth
+	}
 
-		gauges[i+1].Gauge.SetRect(xStart, 7+yOffset*3, xEnd, 10+yOffset*3)
-		gauges[i+1].Gauge.BarColor = ui.ColorGreen
-		gauges[i+1].Gauge.BorderStyle.Fg = ui.ColorBlue
-		gauges[i+1].Gauge.TitleStyle.Fg = ui.ColorCyan
+	// Calculate y position based on position within column
+	yOffset := i
+	if !isLeftColumn {
+		yOffset = i - cpuCount/2
 	}
 
-	// Calculate total height based on the number of rows needed
-	rowsPerColumn := (cpuCount + 1) / 2 // Round up for odd number of cores
-	totalHeight := 7 + rowsPerColumn*3  // Start from y=7
+	// Ensure the right column gauges extend to the full width
+	xEnd := xStart + columnWidth
+	if !isLeftColumn {
+		xEnd = width // Make right column extend to full width
+	}
 
-	return cpuTitle, gauges, totalHeight
+	return xStart, top + yOffset*3, xEnd, top + 3 + yOffset*3
 }
 
-func updateCPUTargets(gauges []CPUGauge) {
+// updateCPUTargets reads the system-wide and per-core utilization into the
+// display's targets, and names the top CPU consumer from state on the average
+// gauge. It returns true when the number of cores changed, in which case the
+// display needs laying out again.
+func updateCPUTargets(d *CPUDisplay, state *sharedState) bool {
+	// The average comes from the aggregate counters rather than the mean of
+	// the per-core values, which diverges on hybrid and hyperthreaded CPUs
+	total, err := cpu.Percent(0, false)
+	if err != nil || len(total) == 0 {
+		log.Printf("Error getting CPU percentages: %v", err)
+		return false
+	}
+
 	// Get percent of each CPU
 	percentages, err := cpu.Percent(0, true)
 	if err != nil {
 		log.Printf("Error getting CPU percentages: %v", err)
-		return
-	}
-
-	// Calculate average
-	var totalPercent float64
-	for _, percent := range percentages {
-		totalPercent += percent
+		return false
 	}
-	avgPercent := totalPercent / float64(len(percentages))
 
-	// Update average gauge target
-	gauges[0].TargetPercent = avgPercent
+	resized := d.SetTargets(total[0], percentages)
+	gauges := d.Gauges
+	gauges[0].Gauge.Title = state.averageTitle(gauges[0].Label)
 
-	// Update individual CPU gauge targets
-	for i, percent := range percentages {
-		if i+1 < len(gauges) {
-			gauges[i+1].TargetPercent = percent
+	// Append each core's current clock speed to its title, which makes
+	// thermal throttling visible as soon as it starts
+	for i, mhz := range cpuFrequencies(len(gauges) - 1) {
+		title := gauges[i+1].Label
+		if mhz > 0 {
+			title += " @ " + formatFrequency(mhz)
 		}
+		gauges[i+1].Gauge.Title = title
+		gauges[i+1].Spark.Title = title
 	}
+	return resized
 }
 
-func animateCPUGauges(gauges []CPUGauge, speed float64) {
+// animateCPUGauges moves each gauge a step toward its target, and returns
+// false when every gauge was already there
+func animateCPUGauges(gauges []CPUGauge, speed float64, thresholds usageThresholds) bool {
+	moved := false
+
 	// Animate all gauges toward their target values
 	for i := range gauges {
 		// Calculate the next step in animation
 		diff := gauges[i].TargetPercent - gauges[i].CurrentPercent
+		if diff != 0 {
+			moved = true
+		}
 
 		// If difference is very small, just snap to the target
 		if abs(diff) < 0.5 {
@@ -594,26 +3549,140 @@ func animateCPUGauges(gauges []CPUGauge, speed float64) {
 		// Update gauge percent
 		intPercent := int(gauges[i].CurrentPercent)
 		gauges[i].Gauge.Percent = intPercent
+		gauges[i].Gauge.Label = ""
+		if gauges[i].Detail != "" {
+			gauges[i].Gauge.Label = fmt.Sprintf("%d%%  %s", intPercent, gauges[i].Detail)
+		}
 
 		// Update color based on usage
-		if intPercent >= 80 {
-			gauges[i].Gauge.BarColor = ui.ColorRed
-		} else if intPercent >= 50 {
-			gauges[i].Gauge.BarColor = ui.ColorYellow
-		} else {
-			gauges[i].Gauge.BarColor = ui.ColorGreen
+		gauges[i].Gauge.BarColor = ui.StyleParserColorMap[thresholds.Color(gauges[i].CurrentPercent)]
+	}
+	return moved
+}
+
+// Render draws the average gauge and the cores in the current view, or as
+// the compact grid when their gauges or sparklines don't fit
+func (d *CPUDisplay) Render() {
+	gauges := d.Gauges
+
+	items := []ui.Drawable{gauges[0].Gauge}
+	if d.Collapsed {
+		render(items...)
+		return
+	}
+	if d.View == CoreHeatmap {
+		d.Heat.Values = d.Heat.Values[:0]
+		for _, g := range gauges[1:] {
+			d.Heat.Values = append(d.Heat.Values, g.CurrentPercent)
+		}
+		d.Heat.Thresholds = d.Thresholds
+		render(append(items, d.Heat)...)
+		return
+	}
+	if d.compact {
+		d.Grid.Text = d.gridText()
+		render(append(items, d.Grid)...)
+		return
+	}
+	if d.grouped() {
+		for gi, group := range d.Groups {
+			d.groupLines[gi].Text = d.groupText(group)
+			items = append(items, d.groupLines[gi])
 		}
+	}
+	for _, g := range gauges[1:] {
+		if d.View != CoreSparklines {
+			items = append(items, g.Gauge)
+			continue
+		}
+		g.Spark.Text = sparkline(g.History.Recent(g.Spark.Inner.Dx()), 100)
+		g.Spark.TextStyle.Fg = g.Gauge.BarColor
+		items = append(items, g.Spark)
+	}
+	render(items...)
+}
+
+func updateCPUGraph(cpuData *CPUData, avgPercent, peakPercent float64, graph *widgets.Plot) {
+	shiftCPUData(cpuData)
+	addCPUData(cpuData, avgPercent, peakPercent)
+	updateCPUMaxValue(cpuData)
+	updateCPUGraphDisplay(cpuData, avgPercent, peakPercent, graph)
+}
+
+// resetCPUData clears the CPU history and its scale
+func resetCPUData(cpuData *CPUData) {
+	clear(cpuData.AvgData)
+	clear(cpuData.PeakData)
+	cpuData.MaxValue = 0.1
+}
+
+func shiftCPUData(cpuData *CPUData) {
+	for i := 0; i < len(cpuData.AvgData)-1; i++ {
+		cpuData.AvgData[i] = cpuData.AvgData[i+1]
+		cpuData.PeakData[i] = cpuData.PeakData[i+1]
+	}
+}
+
+func addCPUData(cpuData *CPUData, avgPercent, peakPercent float64) {
+	cpuData.AvgData[len(cpuData.AvgData)-1] = avgPercent
+	cpuData.PeakData[len(cpuData.PeakData)-1] = peakPercent
+}
+
+func updateCPUMaxValue(cpuData *CPUData) {
+	currentMax := max(maxInSlice(cpuData.AvgData), maxInSlice(cpuData.PeakData))
+	if currentMax > cpuData.MaxValue {
+		cpuData.MaxValue = cpuData.MaxValue + (currentMax-cpuData.MaxValue)*0.3
+	} else if currentMax < cpuData.MaxValue*0.5 && cpuData.MaxValue > 1.0 {
+		cpuData.MaxValue = cpuData.MaxValue - (cpuData.MaxValue-currentMax)*0.05
+	}
 
-		// Render just this gauge
-		ui.Render(gauges[i].Gauge)
+	if cpuData.MaxValue < 0.1 {
+		cpuData.MaxValue = 0.1
 	}
 }
 
-func updateNetworkGraph(netData *NetworkData, rxMbps, txMbps float64, graph *widgets.Plot) {
+func updateCPUGraphDisplay(cpuData *CPUData, avgPercent, peakPercent float64, graph *widgets.Plot) {
+	graph.Data[0] = cpuData.AvgData
+	graph.Data[1] = cpuData.PeakData
+	graph.PlotType = widgets.LineChart
+	graph.AxesColor = ui.ColorClear
+
+	graph.DataLabels = []string{
+		fmt.Sprintf("Avg (%.1f%%)", avgPercent),
+		fmt.Sprintf("Busiest core (%.1f%%)", peakPercent),
+	}
+
+	graph.Title = fmt.Sprintf("CPU History (last %s) - Max: %.1f%%", historySpan(len(cpuData.AvgData), cpuData.Interval), cpuData.MaxValue)
+
+	render(graph)
+}
+
+func updateNetworkGraph(netData *NetworkData, rxBPS, txBPS float64, unit NetUnit, graph, outGraph *widgets.Plot) {
 	shiftNetworkData(netData)
-	addNetworkData(netData, rxMbps, txMbps)
+	addNetworkData(netData, unit.Graph(rxBPS), unit.Graph(txBPS))
 	updateNetworkMaxValue(netData)
-	updateNetworkGraphDisplay(netData, rxMbps, txMbps, graph)
+	updateNetworkGraphDisplay(netData, rxBPS, txBPS, unit, graph, outGraph)
+}
+
+// rescaleNetworkData converts the network history and its scale to a new
+// unit, factor times the old one
+func rescaleNetworkData(netData *NetworkData, factor float64) {
+	for i := range netData.RxData {
+		netData.RxData[i] *= factor
+		netData.TxData[i] *= factor
+	}
+	netData.MaxValue = max(netData.MaxValue*factor, 0.1)
+	netData.RxMax = max(netData.RxMax*factor, 0.1)
+	netData.TxMax = max(netData.TxMax*factor, 0.1)
+}
+
+// resetNetworkData clears the network history and its scale
+func resetNetworkData(netData *NetworkData) {
+	clear(netData.RxData)
+	clear(netData.TxData)
+	netData.MaxValue = 0.1
+	netData.RxMax = 0.1
+	netData.TxMax = 0.1
 }
 
 func shiftNetworkData(netData *NetworkData) {
@@ -629,40 +3698,87 @@ func addNetworkData(netData *NetworkData, rxMbps, txMbps float64) {
 }
 
 func updateNetworkMaxValue(netData *NetworkData) {
-	currentMax := max(maxInSlice(netData.RxData), maxInSlice(netData.TxData))
-	if currentMax > netData.MaxValue {
-		netData.MaxValue = netData.MaxValue + (currentMax-netData.MaxValue)*0.3
-	} else if currentMax < netData.MaxValue*0.5 && netData.MaxValue > 1.0 {
-		netData.MaxValue = netData.MaxValue - (netData.MaxValue-currentMax)*0.05
-	}
+	rxMax, txMax := maxInSlice(netData.RxData), maxInSlice(netData.TxData)
+	netData.MaxValue = smoothMaxValue(netData.MaxValue, max(rxMax, txMax))
+	netData.RxMax = smoothMaxValue(netData.RxMax, rxMax)
+	netData.TxMax = smoothMaxValue(netData.TxMax, txMax)
+}
 
-	if netData.MaxValue < 0.1 {
-		netData.MaxValue = 0.1
+// smoothMaxValue moves a graph's maximum value towards the largest value
+// currently in its history, quickly when rising and slowly when falling, so
+// the scale doesn't jump around
+func smoothMaxValue(maxValue, currentMax float64) float64 {
+	if currentMax > maxValue {
+		maxValue = maxValue + (currentMax-maxValue)*0.3
+	} else if currentMax < maxValue*0.5 && maxValue > 1.0 {
+		maxValue = maxValue - (maxValue-currentMax)*0.05
 	}
+	return max(maxValue, 0.1)
 }
 
-func updateNetworkGraphDisplay(netData *NetworkData, rxMbps, txMbps float64, graph *widgets.Plot) {
-	graph.Data[0] = netData.RxData
-	graph.Data[1] = netData.TxData
+func updateNetworkGraphDisplay(netData *NetworkData, rxBPS, txBPS float64, unit NetUnit, graph, outGraph *widgets.Plot) {
 	graph.PlotType = widgets.LineChart
 	graph.AxesColor = ui.ColorClear
 
 	graph.DataLabels = []string{
-		fmt.Sprintf("In (%.1f Mbps)", rxMbps),
-		fmt.Sprintf("Out (%.1f Mbps)", txMbps),
+		fmt.Sprintf("In (%s)", unit.Format(rxBPS)),
+		fmt.Sprintf("Out (%s)", unit.Format(txBPS)),
 	}
+	outGraph.DataLabels = graph.DataLabels[1:]
 
-	timeSpan := len(netData.RxData) / 2
-	graph.Title = fmt.Sprintf("Network Traffic History (last ~%d seconds) - Max: %.1f Mbps", timeSpan, netData.MaxValue)
+	showNetworkGraphs(netData, unit, graph, outGraph)
+}
+
+// showNetworkGraphs plots the network history, titles the graphs, and
+// draws them. When split, graph shows only received traffic and outGraph
+// sent traffic, each scaled to itself.
+func showNetworkGraphs(netData *NetworkData, unit NetUnit, graph, outGraph *widgets.Plot) {
+	plotNetworkData(netData, graph, outGraph)
+
+	scale := ""
+	if netData.LogScale {
+		scale = " (log)"
+	}
+	timeSpan := historySpan(len(graph.Data[0]), netData.Interval)
+	if !netData.Split {
+		graph.Title = fmt.Sprintf("Network Traffic History%s (last %s) - Max: %.1f %s", scale, timeSpan, netData.MaxValue, unit.GraphUnit())
+		render(graph)
+		return
+	}
+	graph.Title = fmt.Sprintf("Network In History%s (last %s) - Max: %.1f %s", scale, timeSpan, netData.RxMax, unit.GraphUnit())
+	outGraph.Title = fmt.Sprintf("Network Out History%s - Max: %.1f %s", scale, netData.TxMax, unit.GraphUnit())
+	render(graph, outGraph)
+}
 
-	ui.Render(graph)
+// plotNetworkData points the graphs at as much of the network history as
+// they fit, log-scaled copies of it when LogScale is set
+func plotNetworkData(netData *NetworkData, graph, outGraph *widgets.Plot) {
+	rx, tx := fitHistory(netData.RxData, graph), fitHistory(netData.TxData, graph)
+	if netData.LogScale {
+		rx, tx = logScaled(rx), logScaled(tx)
+	}
+	if netData.Split {
+		graph.Data = [][]float64{rx}
+		outGraph.Data = [][]float64{tx}
+	} else {
+		graph.Data = [][]float64{rx, tx}
+	}
 }
 
-func updateDiskGraph(diskData *DiskData, readMBps, writeMBps float64, graph *widgets.Plot) {
+func updateDiskGraph(diskData *DiskData, rates diskDeviceRates, graph, writeGraph *widgets.Plot) {
 	shiftDiskData(diskData)
-	addDiskData(diskData, readMBps, writeMBps)
+	addDiskData(diskData, rates.ReadMBps, rates.WriteMBps)
 	updateDiskMaxValue(diskData)
-	updateDiskGraphDisplay(diskData, readMBps, writeMBps, graph)
+	updateDiskGraphDisplay(diskData, rates, graph, writeGraph)
+}
+
+// resetDiskData clears the disk history and its scale
+func resetDiskData(diskData *DiskData) {
+	clear(diskData.ReadData)
+	clear(diskData.WriteData)
+	diskData.MaxValue = 0.1
+	diskData.ReadMax = 0.1
+	diskData.WriteMax = 0.1
 }
 
 func shiftDiskData(diskData *DiskData) {
@@ -678,33 +3794,146 @@ func addDiskData(diskData *DiskData, readMBps, writeMBps float64) {
 }
 
 func updateDiskMaxValue(diskData *DiskData) {
-	currentMax := max(maxInSlice(diskData.ReadData), maxInSlice(diskData.WriteData))
-	if currentMax > diskData.MaxValue {
-		diskData.MaxValue = diskData.MaxValue + (currentMax-diskData.MaxValue)*0.3
-	} else if currentMax < diskData.MaxValue*0.5 && diskData.MaxValue > 1.0 {
-		diskData.MaxValue = diskData.MaxValue - (diskData.MaxValue-currentMax)*0.05
+	readMax, writeMax := maxInSlice(diskData.ReadData), maxInSlice(diskData.WriteData)
+	diskData.MaxValue = smoothMaxValue(diskData.MaxValue, max(readMax, writeMax))
+	diskData.ReadMax = smoothMaxValue(diskData.ReadMax, readMax)
+	diskData.WriteMax = smoothMaxValue(diskData.WriteMax, writeMax)
+}
+
+func updateDiskGraphDisplay(diskData *DiskData, rates diskDeviceRates, graph, writeGraph *widgets.Plot) {
+	graph.PlotType = widgets.LineChart
+	graph.AxesColor = ui.ColorClear
+
+	graph.DataLabels = []string{
+		fmt.Sprintf("Read (%.2f MB/s, %s)", rates.ReadMBps, formatIOPS(rates.ReadIOPS)),
+		fmt.Sprintf("Write (%.2f MB/s, %s)", rates.WriteMBps, formatIOPS(rates.WriteIOPS)),
+	}
+	writeGraph.DataLabels = graph.DataLabels[1:]
+
+	showDiskGraphs(diskData, graph, writeGraph)
+}
+
+// showDiskGraphs plots the disk history, titles the graphs, and draws them.
+// When split, graph shows only reads and writeGraph writes, each scaled to
+// itself.
+func showDiskGraphs(diskData *DiskData, graph, writeGraph *widgets.Plot) {
+	plotDiskData(diskData, graph, writeGraph)
+
+	scale := ""
+	if diskData.LogScale {
+		scale = " (log)"
+	}
+	busiest := ""
+	if diskData.Busiest != "" {
+		busiest = " - Busiest: " + diskData.Busiest
+	}
+	timeSpan := historySpan(len(graph.Data[0]), diskData.Interval)
+	if !diskData.Split {
+		graph.Title = fmt.Sprintf("Disk I/O History (%s)%s (last %s) - Max: %.2f MB/s%s", diskData.Device, scale, timeSpan, diskData.MaxValue, busiest)
+		render(graph)
+		return
 	}
+	graph.Title = fmt.Sprintf("Disk Read History (%s)%s (last %s) - Max: %.2f MB/s%s", diskData.Device, scale, timeSpan, diskData.ReadMax, busiest)
+	writeGraph.Title = fmt.Sprintf("Disk Write History (%s)%s - Max: %.2f MB/s", diskData.Device, scale, diskData.WriteMax)
+	render(graph, writeGraph)
+}
 
-	if diskData.MaxValue < 0.1 {
-		diskData.MaxValue = 0.1
+// plotDiskData points the graphs at as much of the disk history as they
+// fit, log-scaled copies of it when LogScale is set
+func plotDiskData(diskData *DiskData, graph, writeGraph *widgets.Plot) {
+	read, write := fitHistory(diskData.ReadData, graph), fitHistory(diskData.WriteData, graph)
+	if diskData.LogScale {
+		read, write = logScaled(read), logScaled(write)
+	}
+	if diskData.Split {
+		graph.Data = [][]float64{read}
+		writeGraph.Data = [][]float64{write}
+	} else {
+		graph.Data = [][]float64{read, write}
 	}
 }
 
-func updateDiskGraphDisplay(diskData *DiskData, readMBps, writeMBps float64, graph *widgets.Plot) {
-	graph.Data[0] = diskData.ReadData
-	graph.Data[1] = diskData.WriteData
+// createSplitGraph creates the lower of a pair of split history graphs,
+// plotting a single series in color
+func createSplitGraph(color ui.Color, dataPointCount int) *widgets.Plot {
+	graph := widgets.NewPlot()
+	graph.Border = true
+	graph.LineColors[0] = color
+	graph.DrawDirection = widgets.DrawRight
+	graph.TitleStyle.Fg = theme.Title
+	graph.Data = [][]float64{make([]float64, dataPointCount)}
 	graph.PlotType = widgets.LineChart
+	graph.ShowAxes = false
+	graph.HorizontalScale = 1.0
 	graph.AxesColor = ui.ColorClear
+	return graph
+}
 
-	graph.DataLabels = []string{
-		fmt.Sprintf("Read (%.2f MB/s)", readMBps),
-		fmt.Sprintf("Write (%.2f MB/s)", writeMBps),
+// historySpan describes how far back a history of points samples taken
+// every interval reaches, e.g. "~50 seconds" or "~8 minutes"
+func historySpan(points int, interval time.Duration) string {
+	span := time.Duration(points) * interval
+	if span < 2*time.Minute {
+		return fmt.Sprintf("~%d seconds", int(span.Seconds()))
+	}
+	return fmt.Sprintf("~%d minutes", int(span.Minutes()))
+}
+
+// historyPoints is how many samples the history graphs keep in a terminal
+// width columns wide: as many as a full-width graph plots, so the newest
+// sample is drawn against the right edge. Graphs are only drawn
+// fullMinWidth columns wide or wider, so a narrower terminal keeps that
+// many rather than dropping history nothing would show anyway.
+func historyPoints(width int) int {
+	if width < fullMinWidth {
+		width = fullMinWidth
 	}
+	return graphPoints(width - 2)
+}
+
+// graphPoints is how many samples a line graph plots in inner columns. Each
+// sample is a column apart, and each line is drawn up to but not including
+// its right end, so one more sample than columns fills the last column.
+func graphPoints(inner int) int {
+	return inner + 1
+}
+
+// resizeHistory returns a copy of a history resized to points samples,
+// keeping the newest: a shorter history drops its oldest samples and a
+// longer one is padded with zeros before them
+func resizeHistory(data []float64, points int) []float64 {
+	resized := make([]float64, points)
+	if len(data) > points {
+		data = data[len(data)-points:]
+	}
+	copy(resized[points-len(data):], data)
+	return resized
+}
+
+// fitHistory returns the newest samples of a history that fit in graph,
+// which is narrower than a full-width graph when something shares its rows
+func fitHistory(data []float64, graph *widgets.Plot) []float64 {
+	if fit := graphPoints(graph.Inner.Dx()); fit > 2 && len(data) > fit {
+		return data[len(data)-fit:]
+	}
+	return data
+}
 
-	timeSpan := len(diskData.ReadData) / 2
-	graph.Title = fmt.Sprintf("Disk I/O History (last ~%d seconds) - Max: %.2f MB/s", timeSpan, diskData.MaxValue)
+// logScaleFloor is the smallest value a log-scaled graph tells apart from
+// zero, 1 Kbps or about 1 KB/s in the graphs' units. Smaller values,
+// including zero, are clamped to it so they plot at the baseline instead of
+// at -Inf.
+const logScaleFloor = 0.001
 
-	ui.Render(graph)
+// logScaled returns a copy of values on a log scale, as decades above
+// logScaleFloor, so that bursts hundreds of times the usual rate don't
+// flatten everything else
+func logScaled(values []float64) []float64 {
+	scaled := make([]float64, len(values))
+	for i, v := range values {
+		scaled[i] = math.Log10(max(v, logScaleFloor) / logScaleFloor)
+	}
+	return scaled
 }
 
 // Helper functions
@@ -736,45 +3965,255 @@ func abs(x float64) float64 {
 	return x
 }
 
-func updateHeader(p *widgets.Paragraph) {
-	hostInfo, err := host.Info()
+// cpuIdentity describes the processor for the header, read once at startup
+type cpuIdentity struct {
+	Model    string // Model name without trademarks and clock speed, "" if unknown
+	Physical int    // Physical cores, 0 if unknown
+	Logical  int    // Logical cores, i.e. hardware threads
+}
+
+func readCPUIdentity() cpuIdentity {
+	var id cpuIdentity
+	if infos, err := cpu.Info(); err == nil && len(infos) > 0 {
+		id.Model = trimCPUModel(infos[0].ModelName)
+	}
+	id.Physical, _ = cpu.Counts(false)
+	id.Logical, _ = cpu.Counts(true)
+	return id
+}
+
+// cpuModelFluff matches the parts of CPU model names that say nothing about
+// the model: trademark signs, "CPU"/"Processor", core counts that the
+// header shows anyway, integrated graphics, and the base clock
+var cpuModelFluff = regexp.MustCompile(`\((R|TM|r|tm)\)|\s+@\s*[\d.]+\s*[GM]Hz|\b(CPU|Processor|\d+-Core)\b|\s+w(ith|/) Radeon.*$`)
+
+// trimCPUModel shortens a model name such as "Intel(R) Core(TM) i7-10700K
+// CPU @ 3.80GHz" to "Intel Core i7-10700K"
+func trimCPUModel(model string) string {
+	return strings.Join(strings.Fields(cpuModelFluff.ReplaceAllString(model, " ")), " ")
+}
+
+// Cores formats the core counts as e.g. "8C/16T", or "16 cores" when the
+// physical count is unknown
+func (id cpuIdentity) Cores() string {
+	if id.Physical <= 0 {
+		return fmt.Sprintf("%d cores", id.Logical)
+	}
+	return fmt.Sprintf("%dC/%dT", id.Physical, id.Logical)
+}
+
+// headerMounts are the mounts whose free space the header shows
+type headerMounts struct {
+	Root  string // Shown always: "/" or the system drive, unless --root says otherwise
+	Named bool   // Whether Root came from --root and is named in the header
+	Extra string // A separate data mount, such as /home, shown when there is room, or ""
+}
+
+// defaultHeaderRoot returns the mount the header shows by default: the
+// system drive on Windows, and "/" elsewhere
+func defaultHeaderRoot() string {
+	if runtime.GOOS == "windows" {
+		drive := os.Getenv("SystemDrive")
+		if drive == "" {
+			drive = "C:"
+		}
+		return drive + `\`
+	}
+	return "/"
+}
+
+// headerExtraMounts are where the bulk of the data often lives when "/" is
+// a small root: a separate /home, or the data volume of macOS's read-only
+// system volume
+var headerExtraMounts = []string{"/home", "/System/Volumes/Data"}
+
+// newHeaderMounts picks the header's mounts for root, adding the first of
+// headerExtraMounts that is mounted when root is the default
+func newHeaderMounts(root string) headerMounts {
+	m := headerMounts{Root: root, Named: root != defaultHeaderRoot()}
+	if m.Named {
+		return m
+	}
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return m
+	}
+	for _, mount := range headerExtraMounts {
+		for _, part := range partitions {
+			if part.Mountpoint == mount {
+				m.Extra = mount
+				return m
+			}
+		}
+	}
+	return m
+}
+
+// headerField is one of the header's fields and the markup role it's
+// colored with
+type headerField struct {
+	Text string
+	Role string
+	Rank int // Importance, most important first; 0 for the fields always shown
+}
+
+// updateHeader fills in the system information line for a header width
+// cells wide. The line must fit the header's single row, so the hostname and
+// uptime are always shown, then the core count, RAM, battery, disk, clock,
+// OS, and extra mount in that order as long as they fit. The CPU model is
+// only shown in whatever room is left, shortened with an ellipsis if need
+// be.
+func updateHeader(p *widgets.Paragraph, id cpuIdentity, mounts headerMounts, battery *Battery, width int) {
+	hostInfo, err := readHostInfo()
 	if err != nil {
 		log.Printf("Error getting host info: %v", err)
 		p.Text = "Error getting system information"
 		return
 	}
 
-	memInfo, err := mem.VirtualMemory()
-	if err != nil {
-		log.Printf("Error getting memory info: %v", err)
+	uptimeText := "Up: n/a"
+	if uptime, err := host.Uptime(); err == nil {
+		uptimeText = "Up: " + formatUptime(uptime)
+	} else {
+		log.Printf("Error getting uptime: %v", err)
 	}
 
-	cpuCount, err := cpu.Counts(true)
-	if err != nil {
-		log.Printf("Error getting CPU count: %v", err)
+	ramText := "RAM: n/a"
+	if memInfo, err := mem.VirtualMemory(); err == nil {
+		ramText = fmt.Sprintf("RAM: %s / %s (%.1f%%)", formatBytes(memInfo.Used), formatBytes(memInfo.Total), memInfo.UsedPercent)
+	} else {
+		log.Printf("Error getting memory info: %v", err)
 	}
 
 	// Get disk usage information
-	diskInfo, err := disk.Usage("/")
-	if err != nil {
-		log.Printf("Error getting disk info: %v", err)
-	}
-
-	p.Text = fmt.Sprintf(
-		"[Host: %s](fg:cyan) | [OS: %s %s](fg:yellow) | [%d cores](fg:green) | [RAM: %s / %s (%.1f%%)](fg:magenta) | [Disk: %s free / %s total (%.1f%% free)](fg:red)",
-		hostInfo.Hostname,
-		hostInfo.Platform,
-		hostInfo.PlatformVersion,
-		cpuCount,
-		formatBytes(memInfo.Used),
-		formatBytes(memInfo.Total),
-		memInfo.UsedPercent,
-		formatBytes(diskInfo.Free),
-		formatBytes(diskInfo.Total),
-		100-diskInfo.UsedPercent,
-	)
+	diskLabel := "Disk"
+	if mounts.Named {
+		diskLabel = fmt.Sprintf("Disk %s", mounts.Root)
+	}
+	diskText := diskLabel + ": n/a"
+	if diskInfo, err := disk.Usage(mounts.Root); err == nil {
+		diskText = fmt.Sprintf("%s: %s free / %s total (%.1f%% free)", diskLabel, formatBytes(diskInfo.Free), formatBytes(diskInfo.Total), 100-diskInfo.UsedPercent)
+	} else {
+		log.Printf("Error getting disk info for %s: %v", mounts.Root, err)
+	}
+
+	fields := []headerField{
+		{fmt.Sprintf("Host: %s", hostInfo.Hostname), "label", 0},
+		{uptimeText, "label", 0},
+		{fmt.Sprintf("OS: %s %s", hostInfo.Platform, hostInfo.PlatformVersion), "accent", 6},
+		{id.Cores(), "cpu", 1},
+		{ramText, "mem", 2},
+	}
+	if battery.Present {
+		role := "text"
+		switch {
+		case battery.Low():
+			role = "crit"
+		case battery.Status.State == batteryCharging || battery.Status.State == batteryFull:
+			role = "ok"
+		}
+		fields = append(fields, headerField{battery.String(), role, 3})
+	}
+	fields = append(fields, headerField{diskText, "write", 4})
+	if mounts.Extra != "" {
+		if extraInfo, err := disk.Usage(mounts.Extra); err == nil {
+			extraText := fmt.Sprintf("%s: %s free (%.1f%%)", mounts.Extra, formatBytes(extraInfo.Free), 100-extraInfo.UsedPercent)
+			fields = append(fields, headerField{extraText, "write", 7})
+		}
+	}
+	fields = append(fields, headerField{time.Now().Format("15:04"), "text", 5})
+
+	room := width - 2 - 1 // Less the header's border, and a cell so the line doesn't wrap
+	keep := make([]bool, len(fields))
+	kept := func() []headerField {
+		var shown []headerField
+		for i, f := range fields {
+			if keep[i] {
+				shown = append(shown, f)
+			}
+		}
+		return shown
+	}
+	for i, f := range fields {
+		keep[i] = f.Rank == 0
+	}
+	if excess := headerWidth(kept()) - room; excess > 0 {
+		// Even the hostname and uptime don't fit; shorten the hostname
+		hostText := fields[0].Text
+		fields[0].Text = truncateToWidth(hostText, displayWidth(hostText)-excess-1) + "…"
+	}
+	order := make([]int, len(fields))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return fields[a].Rank - fields[b].Rank })
+	for _, i := range order {
+		if !keep[i] {
+			keep[i] = true
+			if headerWidth(kept()) > room {
+				keep[i] = false
+			}
+		}
+	}
+	fields = kept()
+
+	// Give the model whatever the other fields and separators leave
+	if id.Model != "" {
+		for i := range fields {
+			if fields[i].Role != "cpu" {
+				continue
+			}
+			left := room - headerWidth(fields) - 1
+			model := id.Model
+			if displayWidth(model) > left {
+				model = truncateToWidth(model, left-1) + "…"
+			}
+			if left >= headerMinModelWidth {
+				fields[i].Text = model + " " + fields[i].Text
+			}
+		}
+	}
+
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = fmt.Sprintf("[%s](fg:%s)", f.Text, f.Role)
+	}
+	p.Text = strings.Join(parts, " | ")
+}
+
+// headerWidth returns how many cells the fields take with their separators
+func headerWidth(fields []headerField) int {
+	width := 0
+	for i, f := range fields {
+		if i > 0 {
+			width += len(" | ")
+		}
+		width += displayWidth(f.Text)
+	}
+	return width
+}
+
+// showPaused marks the header while updates are paused, so frozen figures
+// aren't mistaken for live ones
+func showPaused(p *widgets.Paragraph, paused bool) {
+	p.Title = "SysGoMon"
+	p.TitleStyle = ui.NewStyle(theme.Title)
+	p.BorderStyle = ui.NewStyle(theme.Border)
+	if paused {
+		p.Title = "SysGoMon [PAUSED - h to resume]"
+		p.TitleStyle = ui.NewStyle(theme.Crit, ui.ColorClear, ui.ModifierBold)
+		p.BorderStyle = ui.NewStyle(theme.Crit)
+	}
 }
 
+// readHostInfo returns the hostname and OS shown in the header, read once
+// since they don't change while running
+var readHostInfo = sync.OnceValues(host.Info)
+
+// headerMinModelWidth is the narrowest space worth showing a shortened CPU
+// model name in
+const headerMinModelWidth = 8
+
 func formatBytes(bytes uint64) string {
 	const unit = 1024
 	if bytes < unit {
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestFormatCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		cmd   string
+		width int
+		want  string
+	}{
+		{"fits", "/usr/bin/top", 20, "/usr/bin/top"},
+		{"ascii", "/usr/bin/python3 app.py", 10, "/usr/bi..."},
+		{"zero width", "top", 0, ""},
+		{"negative width", "top", -1, ""},
+		{"width 1", "python3", 1, "p"},
+		{"width 2", "python3", 2, "py"},
+		{"width 3", "python3", 3, "..."},
+		{"cjk", "日本語のコマンド", 9, "日本語..."},
+		{"cjk split", "日本語のコマンド", 8, "日本..."},
+		{"cjk narrow", "日本語", 1, ""},
+		{"emoji", "🚀🚀🚀 launch", 7, "🚀🚀..."},
+		{"emoji split", "🚀🚀🚀 launch", 6, "🚀..."},
+		{"combining", "cafe\u0301 --bar", 7, "cafe\u0301..."},
+		{"combining fits", "cafe\u0301", 4, "cafe\u0301"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatCommand(tt.cmd, tt.width)
+			if got != tt.want {
+				t.Errorf("formatCommand(%q, %d) = %q, want %q", tt.cmd, tt.width, got, tt.want)
+			}
+			if w := displayWidth(got); w > tt.width && tt.width > 0 {
+				t.Errorf("formatCommand(%q, %d) is %d cells wide", tt.cmd, tt.width, w)
+			}
+		})
+	}
+}
+
+func TestTruncateToWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+		want  string
+	}{
+		{"ascii", "abcdef", 3, "abc"},
+		{"fits", "abc", 5, "abc"},
+		{"zero", "abc", 0, ""},
+		{"cjk whole", "日本語", 4, "日本"},
+		{"cjk half", "日本語", 3, "日"},
+		{"emoji", "a😀b", 2, "a"},
+		{"emoji whole", "a😀b", 3, "a😀"},
+		{"combining kept", "e\u0301e\u0301", 1, "e\u0301"},
+		{"combining after wide", "日\u0301x", 2, "日\u0301"},
+		{"enclosing mark", "1\u20e32", 1, "1\u20e3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateToWidth(tt.s, tt.width); got != tt.want {
+				t.Errorf("truncateToWidth(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortProcessesStable(t *testing.T) {
+	processes := []ProcessInfo{
+		{PID: 40, Name: "beta", CPU: 5},
+		{PID: 10, Name: "gamma", CPU: 5},
+		{PID: 30, Name: "alpha", CPU: 9},
+		{PID: 20, Name: "alpha", CPU: 5},
+		{PID: 50, Name: "delta", CPU: 5, Pinned: true},
+	}
+	tests := []struct {
+		name    string
+		grouped bool
+		byUser  bool
+		want    []int32
+	}{
+		// Equal CPU falls back to the PID
+		{"pid tiebreak", false, false, []int32{50, 30, 10, 20, 40}},
+		// Grouped and per-user rows fall back to the name
+		{"grouped", true, false, []int32{50, 30, 20, 40, 10}},
+		{"by user", false, true, []int32{50, 30, 20, 40, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := &ProcessList{SortKey: SortByCPU, Grouped: tt.grouped, ByUser: tt.byUser}
+			pl.Processes = append([]ProcessInfo(nil), processes...)
+			pl.sortProcesses()
+			first := pids(pl.Processes)
+
+			// Sorting an already sorted list, or the input reversed, must
+			// give the same order
+			pl.sortProcesses()
+			again := pids(pl.Processes)
+			for i, j := 0, len(pl.Processes)-1; i < j; i, j = i+1, j-1 {
+				pl.Processes[i], pl.Processes[j] = pl.Processes[j], pl.Processes[i]
+			}
+			pl.sortProcesses()
+			reversed := pids(pl.Processes)
+
+			for _, got := range [][]int32{first, again, reversed} {
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("order = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func pids(processes []ProcessInfo) []int32 {
+	out := make([]int32, len(processes))
+	for i, p := range processes {
+		out[i] = p.PID
+	}
+	return out
+}
+
+func TestCPUDisplaySetTargets(t *testing.T) {
+	tests := []struct {
+		name        string
+		percentages []float64
+		wantCores   int
+		wantResized bool
+	}{
+		{"equal", []float64{10, 20, 30, 40}, 4, false},
+		{"fewer", []float64{10, 20}, 2, true},
+		{"more", []float64{10, 20, 30, 40, 50, 60}, 6, true},
+		{"empty", nil, 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &CPUDisplay{Gauges: []CPUGauge{newCPUGauge("Avg CPU")}}
+			for i := 1; i <= 4; i++ {
+				gauge := newCPUGauge(fmt.Sprintf("CPU %d", i))
+				gauge.TargetPercent = 99
+				d.Gauges = append(d.Gauges, gauge)
+			}
+
+			if resized := d.SetTargets(25, tt.percentages); resized != tt.wantResized {
+				t.Errorf("SetTargets resized = %v, want %v", resized, tt.wantResized)
+			}
+			if cores := len(d.Gauges) - 1; cores != tt.wantCores {
+				t.Fatalf("%d core gauges, want %d", cores, tt.wantCores)
+			}
+			if d.Gauges[0].TargetPercent != 25 {
+				t.Errorf("average target = %v, want 25", d.Gauges[0].TargetPercent)
+			}
+			for i, gauge := range d.Gauges[1:] {
+				// An empty slice leaves the cores as they were
+				want, samples := 99.0, 0
+				if len(tt.percentages) > 0 {
+					want, samples = tt.percentages[i], 1
+				}
+				if gauge.TargetPercent != want {
+					t.Errorf("core %d target = %v, want %v", i, gauge.TargetPercent, want)
+				}
+				if got := len(gauge.History.Recent(10)); got != samples {
+					t.Errorf("core %d has %d samples, want %d", i, got, samples)
+				}
+			}
+		})
+	}
+}
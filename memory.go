@@ -0,0 +1,341 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// Heights of the memory section's gauge, readout lines, and history graph.
+// The hugepage line is only shown on machines with hugepages.
+const (
+	memGaugeHeight = 3
+	memInfoHeight  = 1
+	memGraphHeight = 7
+)
+
+// bytesPerGB converts byte counts to the GB (GiB) figures the graph plots,
+// matching formatBytes
+const bytesPerGB = 1 << 30
+
+// MemoryData stores memory usage history for graphing
+type MemoryData struct {
+	UsedData  []float64     // History of used memory in GB
+	AvailData []float64     // History of available memory in GB
+	MaxValue  float64       // Maximum value for scaling
+	Interval  time.Duration // Time between samples
+}
+
+// swapRates tracks how fast pages move to and from swap, from the Sin and
+// Sout counters of mem.SwapMemory. Steady swapping, rather than swap merely
+// being in use, is what makes a machine unresponsive.
+type swapRates struct {
+	In    float64 // Bytes swapped in per second
+	Out   float64 // Bytes swapped out per second
+	Valid bool    // Whether two comparable samples have been seen
+
+	sin, sout uint64    // Counters at the last sample
+	last      time.Time // When the last sample was taken
+}
+
+// Update takes a new sample of the swap counters and recomputes the rates.
+// Like kernelRates, the first sample only sets the baseline, as does one
+// taken after a suspend or after the counters went backwards.
+func (s *swapRates) Update(sin, sout uint64) {
+	now := time.Now()
+	prev, prevIn, prevOut := s.last, s.sin, s.sout
+	s.sin, s.sout, s.last = sin, sout, now
+	elapsed, ok := sampleElapsed(prev, now)
+	if !ok {
+		s.Valid = false
+		return
+	}
+	var okIn, okOut bool
+	s.In, okIn = counterRate(prevIn, sin, elapsed)
+	s.Out, okOut = counterRate(prevOut, sout, elapsed)
+	s.Valid = okIn && okOut
+}
+
+// memorySegment is one named part of the memory breakdown
+type memorySegment struct {
+	Label string
+	Bytes uint64
+}
+
+// memoryBreakdown splits memory into the parts the platform reports. Linux
+// counts the page cache as used, so Available, Cached, Buffers, and Shared
+// show how much of it could be reclaimed; macOS reports wired, active, and
+// inactive pages instead. Segments the platform leaves at zero are omitted
+// rather than shown as misleading zeros.
+func memoryBreakdown(vm *mem.VirtualMemoryStat) []memorySegment {
+	segments := []memorySegment{{"Avail", vm.Available}}
+	switch runtime.GOOS {
+	case "linux":
+		segments = append(segments,
+			memorySegment{"Cached", vm.Cached},
+			memorySegment{"Buffers", vm.Buffers},
+			memorySegment{"Shared", vm.Shared})
+	case "darwin":
+		segments = append(segments,
+			memorySegment{"Wired", vm.Wired},
+			memorySegment{"Active", vm.Active},
+			memorySegment{"Inactive", vm.Inactive})
+	}
+
+	present := segments[:0]
+	for _, s := range segments {
+		if s.Bytes > 0 {
+			present = append(present, s)
+		}
+	}
+	return present
+}
+
+// memoryPressure returns the percentage of memory that isn't available to
+// new allocations, which unlike UsedPercent doesn't count reclaimable cache
+func memoryPressure(vm *mem.VirtualMemoryStat) float64 {
+	if vm.Total == 0 || vm.Available > vm.Total {
+		return vm.UsedPercent
+	}
+	return float64(vm.Total-vm.Available) / float64(vm.Total) * 100
+}
+
+// MemorySection shows RAM usage as a gauge above a readout line with the
+// memory breakdown, swap usage, and memory pressure, and a history graph of used and available
+// memory
+type MemorySection struct {
+	Gauge      *widgets.Gauge
+	Info       *widgets.Paragraph
+	Hugepages  *widgets.Paragraph
+	Graph      *widgets.Plot
+	Data       MemoryData
+	Thresholds usageThresholds // Usage at which the gauge turns yellow and red
+	PSI        usageThresholds // Memory pressure at which it turns yellow and red
+
+	swap          *mem.SwapMemoryStat // Latest swap usage, nil when unavailable
+	swapRate      swapRates
+	showHugepages bool // Whether the hugepage line is laid out
+}
+
+// createMemorySection builds the section with room for points samples of
+// history
+func createMemorySection(points int) *MemorySection {
+	m := &MemorySection{
+		Gauge:      widgets.NewGauge(),
+		Info:       widgets.NewParagraph(),
+		Hugepages:  widgets.NewParagraph(),
+		Graph:      widgets.NewPlot(),
+		Thresholds: defaultCPUThresholds,
+		PSI:        defaultPSIThresholds,
+		Data: MemoryData{
+			UsedData:  make([]float64, points),
+			AvailData: make([]float64, points),
+			MaxValue:  0.1, // Start with a small non-zero value
+		},
+	}
+	m.Gauge.Title = "Memory"
+	m.Gauge.BarColor = theme.Ok
+	m.Gauge.BorderStyle.Fg = theme.Frame
+	m.Gauge.TitleStyle.Fg = theme.Label
+
+	m.Info.Border = false
+	m.Hugepages.Border = false
+
+	m.Graph.Title = "Memory History (GB)"
+	m.Graph.Border = true
+	m.Graph.LineColors[0] = theme.MemUsed  // Used
+	m.Graph.LineColors[1] = theme.MemAvail // Available
+	m.Graph.DrawDirection = widgets.DrawRight
+	m.Graph.TitleStyle.Fg = theme.Title
+	m.Graph.Data = [][]float64{m.Data.UsedData, m.Data.AvailData}
+	m.Graph.PlotType = widgets.LineChart
+	m.Graph.ShowAxes = false
+	m.Graph.HorizontalScale = 1.0
+	m.Graph.AxesColor = ui.ColorClear
+	return m
+}
+
+// Height returns the rows the section takes
+func (m *MemorySection) Height() int {
+	height := memGaugeHeight + memInfoHeight + memGraphHeight
+	if m.showHugepages {
+		height += memInfoHeight
+	}
+	return height
+}
+
+// Layout places the section at row top and returns the row below it
+func (m *MemorySection) Layout(top, width int) int {
+	m.Gauge.SetRect(0, top, width, top+memGaugeHeight)
+	top += memGaugeHeight
+	m.Info.SetRect(0, top, width, top+memInfoHeight)
+	top += memInfoHeight
+	if m.showHugepages {
+		m.Hugepages.SetRect(0, top, width, top+memInfoHeight)
+		top += memInfoHeight
+	}
+	m.Graph.SetRect(0, top, width, top+memGraphHeight)
+	return top + memGraphHeight
+}
+
+// Resize trims or pads the history to points samples, keeping the recent
+// ones
+func (m *MemorySection) Resize(points int) {
+	if points == len(m.Data.UsedData) {
+		return
+	}
+	m.Data.UsedData = resizeHistory(m.Data.UsedData, points)
+	m.Data.AvailData = resizeHistory(m.Data.AvailData, points)
+	m.Graph.Data[0] = m.Data.UsedData
+	m.Graph.Data[1] = m.Data.AvailData
+}
+
+// Update reads current memory and swap usage into the gauge, readout, and
+// graph. It reports whether the hugepage line appeared or disappeared,
+// changing the section's height.
+func (m *MemorySection) Update() bool {
+	m.updateSwap()
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		log.Printf("Error getting memory info: %v", err)
+		m.Info.Text = m.swapText()
+		return false
+	}
+	parts := []string{breakdownText(vm), m.swapText()}
+	if stat, ok := readMemoryPressure(); ok {
+		parts = append(parts, pressureText(stat, m.PSI))
+	}
+	m.Info.Text = strings.Join(parts, "  ")
+	m.Hugepages.Text = hugepageText(vm)
+
+	m.Gauge.Percent = int(vm.UsedPercent)
+	m.Gauge.Label = fmt.Sprintf("%s / %s (%.1f%%)", formatBytes(vm.Used), formatBytes(vm.Total), vm.UsedPercent)
+	m.Gauge.BarColor = ui.StyleParserColorMap[m.Thresholds.Color(memoryPressure(vm))]
+
+	updateMemoryGraph(&m.Data, float64(vm.Used)/bytesPerGB, float64(vm.Available)/bytesPerGB, m.Graph)
+
+	show := m.Hugepages.Text != ""
+	resized := show != m.showHugepages
+	m.showHugepages = show
+	return resized
+}
+
+// hugepageText renders hugepage usage and the kernel's commit accounting,
+// e.g. "Hugepages: 1024 / 2048 used (2.0 MB) Commit: 12.3 GB / 16.0 GB
+// (77%)". Reserved hugepages count as used memory whether or not anything
+// has mapped them, which this makes visible. It is "" on machines without
+// hugepages, where the commit figures alone aren't worth a line.
+func hugepageText(vm *mem.VirtualMemoryStat) string {
+	if vm.HugePagesTotal == 0 {
+		return ""
+	}
+	text := fmt.Sprintf("[Hugepages:](fg:label) %d / %d used (%s)",
+		vm.HugePagesTotal-vm.HugePagesFree, vm.HugePagesTotal, formatBytes(vm.HugePageSize))
+	if vm.CommitLimit > 0 {
+		text += fmt.Sprintf(" [Commit:](fg:label) %s / %s (%.0f%%)",
+			formatBytes(vm.CommittedAS), formatBytes(vm.CommitLimit), float64(vm.CommittedAS)/float64(vm.CommitLimit)*100)
+	}
+	return text
+}
+
+// breakdownText renders the memory breakdown, e.g. "Avail: 9.1 GB Cached:
+// 6.0 GB Buffers: 312.0 MB Shared: 1.1 GB"
+func breakdownText(vm *mem.VirtualMemoryStat) string {
+	var parts []string
+	for _, s := range memoryBreakdown(vm) {
+		parts = append(parts, fmt.Sprintf("[%s:](fg:label) %s", s.Label, formatBytes(s.Bytes)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// updateSwap reads swap usage and the swap-in/out counters
+func (m *MemorySection) updateSwap() {
+	swap, err := mem.SwapMemory()
+	if err != nil {
+		m.swap = nil
+		m.swapRate.Valid = false
+		return
+	}
+	m.swap = swap
+	m.swapRate.Update(swap.Sin, swap.Sout)
+}
+
+// swapText renders swap usage and its in/out rates, or "none" on machines
+// without swap configured
+func (m *MemorySection) swapText() string {
+	switch {
+	case m.swap == nil:
+		return "[Swap:](fg:label) n/a"
+	case m.swap.Total == 0:
+		return "[Swap:](fg:label) none"
+	}
+	text := fmt.Sprintf("[Swap:](fg:label) %s / %s", formatBytes(m.swap.Used), formatBytes(m.swap.Total))
+	if m.swapRate.Valid {
+		text += fmt.Sprintf(" [In:](fg:label) %s [Out:](fg:label) %s",
+			swapRateText(m.swapRate.In), swapRateText(m.swapRate.Out))
+	}
+	return text
+}
+
+// swapRateText formats a swap rate, in red while pages are actually moving
+func swapRateText(bps float64) string {
+	if bps > 0 {
+		return fmt.Sprintf("[%s](fg:crit)", formatRate(bps))
+	}
+	return formatRate(bps)
+}
+
+// Drawables returns the section's widgets for rendering
+func (m *MemorySection) Drawables() []ui.Drawable {
+	if m.showHugepages {
+		return []ui.Drawable{m.Gauge, m.Info, m.Hugepages, m.Graph}
+	}
+	return []ui.Drawable{m.Gauge, m.Info, m.Graph}
+}
+
+func updateMemoryGraph(memData *MemoryData, usedGB, availGB float64, graph *widgets.Plot) {
+	shiftMemoryData(memData)
+	addMemoryData(memData, usedGB, availGB)
+	updateMemoryMaxValue(memData)
+	updateMemoryGraphDisplay(memData, usedGB, availGB, graph)
+}
+
+func shiftMemoryData(memData *MemoryData) {
+	for i := 0; i < len(memData.UsedData)-1; i++ {
+		memData.UsedData[i] = memData.UsedData[i+1]
+		memData.AvailData[i] = memData.AvailData[i+1]
+	}
+}
+
+func addMemoryData(memData *MemoryData, usedGB, availGB float64) {
+	memData.UsedData[len(memData.UsedData)-1] = usedGB
+	memData.AvailData[len(memData.AvailData)-1] = availGB
+}
+
+func updateMemoryMaxValue(memData *MemoryData) {
+	currentMax := max(maxInSlice(memData.UsedData), maxInSlice(memData.AvailData))
+	if currentMax > memData.MaxValue {
+		memData.MaxValue = memData.MaxValue + (currentMax-memData.MaxValue)*0.3
+	} else if currentMax < memData.MaxValue*0.5 && memData.MaxValue > 1.0 {
+		memData.MaxValue = memData.MaxValue - (memData.MaxValue-currentMax)*0.05
+	}
+
+	if memData.MaxValue < 0.1 {
+		memData.MaxValue = 0.1
+	}
+}
+
+func updateMemoryGraphDisplay(memData *MemoryData, usedGB, availGB float64, graph *widgets.Plot) {
+	graph.DataLabels = []string{
+		fmt.Sprintf("Used (%.1f GB)", usedGB),
+		fmt.Sprintf("Available (%.1f GB)", availGB),
+	}
+
+	graph.Title = fmt.Sprintf("Memory History (last %s) - Max: %.1f GB", historySpan(len(memData.UsedData), memData.Interval), memData.MaxValue)
+}
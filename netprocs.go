@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// netProcInterval is how often the network processes panel is refreshed.
+// Attributing sockets to processes means walking every process's file
+// descriptors, so it is only done while the panel is open.
+const netProcInterval = 5 * time.Second
+
+// netProcNameWidth is the width of the panel's process name column
+const netProcNameWidth = 20
+
+// netProcess is the network use of every process sharing a name, so that
+// the workers of a server are counted together
+type netProcess struct {
+	Name  string
+	Procs int     // Processes with this name holding connections
+	Conns int     // Open TCP connections, not counting listening sockets
+	Rate  float64 // Bytes sent and received per second, when measured
+}
+
+// netProcSnapshot is the result of one pass of the network processes
+// collector
+type netProcSnapshot struct {
+	Processes []netProcess // Busiest first
+	Rates     bool         // Whether per-process bandwidth was measured
+	Limited   bool         // Only the current user's sockets could be attributed
+	Err       error
+}
+
+// pidNetUse is one process's TCP connections in a sample
+type pidNetUse struct {
+	Conns int
+	Rate  float64 // Bytes sent and received per second, when measured
+}
+
+// netProcSample is what a platform's netProcSampler reports for one pass
+type netProcSample struct {
+	Use     map[int32]*pidNetUse // By PID
+	Rates   bool                 // Whether Rate was measured
+	Limited bool                 // Only the current user's sockets could be attributed
+}
+
+// NetProcessCollector samples per-process network use on its own goroutine
+// every netProcInterval, publishing the results on Snapshots
+type NetProcessCollector struct {
+	Snapshots chan netProcSnapshot // Latest per-process network use
+	done      chan struct{}        // Closed by Stop to end the goroutine
+	wg        sync.WaitGroup       // Tracks the running goroutine
+}
+
+func NewNetProcessCollector() *NetProcessCollector {
+	return &NetProcessCollector{
+		Snapshots: make(chan netProcSnapshot, 1),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start launches the collector goroutine
+func (c *NetProcessCollector) Start() {
+	c.wg.Add(1)
+	go c.run()
+}
+
+// Stop ends the collector goroutine and waits for it to exit
+func (c *NetProcessCollector) Stop() {
+	close(c.done)
+	c.wg.Wait()
+}
+
+func (c *NetProcessCollector) run() {
+	defer c.wg.Done()
+	sampler := newNetProcSampler()
+	ticker := time.NewTicker(netProcInterval)
+	defer ticker.Stop()
+	for {
+		snap := collectNetProcesses(sampler)
+
+		// Replace any snapshot the UI has not picked up yet
+		select {
+		case <-c.Snapshots:
+		default:
+		}
+		select {
+		case c.Snapshots <- snap:
+		case <-c.done:
+			return
+		}
+
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectNetProcesses takes a sample and combines the processes in it by
+// name, busiest first
+func collectNetProcesses(sampler *netProcSampler) netProcSnapshot {
+	sample, err := sampler.Sample()
+	if err != nil {
+		return netProcSnapshot{Err: err}
+	}
+
+	byName := make(map[string]*netProcess)
+	for pid, use := range sample.Use {
+		name := fmt.Sprintf("pid %d", pid)
+		if p, err := process.NewProcess(pid); err == nil {
+			if n, err := p.Name(); err == nil && n != "" {
+				name = n
+			}
+		}
+		np, ok := byName[name]
+		if !ok {
+			np = &netProcess{Name: name}
+			byName[name] = np
+		}
+		np.Procs++
+		np.Conns += use.Conns
+		np.Rate += use.Rate
+	}
+
+	snap := netProcSnapshot{Rates: sample.Rates, Limited: sample.Limited}
+	for _, np := range byName {
+		snap.Processes = append(snap.Processes, *np)
+	}
+	sort.Slice(snap.Processes, func(i, j int) bool {
+		a, b := snap.Processes[i], snap.Processes[j]
+		if a.Rate != b.Rate {
+			return a.Rate > b.Rate
+		}
+		if a.Conns != b.Conns {
+			return a.Conns > b.Conns
+		}
+		return a.Name < b.Name
+	})
+	return snap
+}
+
+// NetProcessPanel lists the processes with the most network traffic, or
+// the most connections where traffic can't be measured. It is drawn over
+// the disk section while open, and only collects while open.
+type NetProcessPanel struct {
+	*widgets.Paragraph
+	Active    bool
+	Unit      NetUnit // Unit the rates are shown in
+	collector *NetProcessCollector
+	last      netProcSnapshot // Snapshot shown, kept to redraw when Unit changes
+	loaded    bool            // Whether a snapshot has arrived since opening
+}
+
+func createNetProcessPanel() *NetProcessPanel {
+	p := &NetProcessPanel{Paragraph: widgets.NewParagraph()}
+	p.Border = true
+	p.WrapText = false
+	p.TitleStyle.Fg = theme.Title
+	p.BorderStyle.Fg = theme.Panel
+	return p
+}
+
+// Open shows the panel and starts collecting
+func (p *NetProcessPanel) Open() {
+	if p.Active {
+		return
+	}
+	p.Active = true
+	p.loaded = false
+	p.Title = "Network Processes (B to close)"
+	p.Text = "[Collecting...](fg:warn)"
+	p.collector = NewNetProcessCollector()
+	p.collector.Start()
+}
+
+// Close hides the panel and stops collecting
+func (p *NetProcessPanel) Close() {
+	if !p.Active {
+		return
+	}
+	p.Active = false
+	p.collector.Stop()
+	p.collector = nil
+}
+
+// Snapshots returns the channel the collector publishes on, or nil while
+// the panel is closed so that receiving from it blocks
+func (p *NetProcessPanel) Snapshots() <-chan netProcSnapshot {
+	if p.collector == nil {
+		return nil
+	}
+	return p.collector.Snapshots
+}
+
+// Update shows a new snapshot
+func (p *NetProcessPanel) Update(snap netProcSnapshot) {
+	p.last = snap
+	p.loaded = true
+	p.refresh()
+}
+
+// refresh rebuilds the text from the last snapshot, such as after the rate
+// unit changes
+func (p *NetProcessPanel) refresh() {
+	snap := p.last
+	p.Title = "Network Processes (B to close)"
+	if snap.Limited {
+		p.Title = "Network Processes, own processes only (B to close)"
+	}
+	if snap.Err != nil {
+		p.Text = fmt.Sprintf("[Error listing connections: %v](fg:crit)", snap.Err)
+		return
+	}
+
+	header := fmt.Sprintf("%-*s %5s %7s", netProcNameWidth, "Process", "Procs", "Conns")
+	if snap.Rates {
+		header += fmt.Sprintf(" %13s", "Rate")
+	}
+	lines := []string{fmt.Sprintf("[%s](fg:label)", header)}
+	for _, np := range snap.Processes {
+		if len(lines) >= p.Inner.Dy() {
+			break
+		}
+		line := fmt.Sprintf("%-*s %5d %7d", netProcNameWidth, truncateToWidth(np.Name, netProcNameWidth), np.Procs, np.Conns)
+		if snap.Rates {
+			line += fmt.Sprintf(" %13s", p.Unit.Format(np.Rate))
+		}
+		lines = append(lines, line)
+	}
+	if len(snap.Processes) == 0 {
+		lines = append(lines, "No processes with open connections")
+	}
+	p.Text = strings.Join(lines, "\n")
+}
+
+// SetUnit changes the unit the rates are shown in
+func (p *NetProcessPanel) SetUnit(u NetUnit) {
+	p.Unit = u
+	if p.Active && p.loaded {
+		p.refresh()
+	}
+}
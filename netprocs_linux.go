@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Constants from linux/sock_diag.h, linux/inet_diag.h, and linux/tcp.h
+// that package syscall doesn't define
+const (
+	sockDiagByFamily = 20 // SOCK_DIAG_BY_FAMILY request type
+	inetDiagInfo     = 2  // INET_DIAG_INFO attribute, holding a struct tcp_info
+	inetDiagReqLen   = 56 // Size of struct inet_diag_req_v2
+	inetDiagMsgLen   = 72 // Size of struct inet_diag_msg
+	inetDiagInodeOff = 68 // Offset of idiag_inode in struct inet_diag_msg
+	tcpListenState   = 10 // TCP_LISTEN
+
+	// Offsets of tcpi_bytes_acked and tcpi_bytes_received in struct
+	// tcp_info, present since Linux 4.1
+	tcpInfoBytesAckedOff    = 120
+	tcpInfoBytesReceivedOff = 128
+)
+
+// netProcSampler measures each process's TCP traffic. The kernel's
+// sock_diag interface reports every socket's byte counters without root,
+// and each socket is attributed to a process by finding its inode among
+// the process's file descriptors, which without root is only possible for
+// the current user's processes.
+type netProcSampler struct {
+	prev map[uint32]uint64 // Bytes each socket had carried at the last sample, by inode
+	last time.Time         // When the last sample was taken
+}
+
+func newNetProcSampler() *netProcSampler {
+	return &netProcSampler{prev: make(map[uint32]uint64)}
+}
+
+// Sample counts each process's TCP connections and the rate they carried
+// traffic at since the previous sample
+func (s *netProcSampler) Sample() (netProcSample, error) {
+	owners := socketOwners()
+	now := time.Now()
+	elapsed, valid := sampleElapsed(s.last, now)
+	prev := s.prev
+	s.prev = make(map[uint32]uint64, len(prev))
+	s.last = now
+
+	sample := netProcSample{Use: make(map[int32]*pidNetUse), Rates: true, Limited: os.Geteuid() != 0}
+	dumped := false
+	var dumpErr error
+	for _, family := range []uint8{syscall.AF_INET, syscall.AF_INET6} {
+		socks, err := dumpTCPSockets(family)
+		if err != nil {
+			// IPv6 may be disabled, so only fail if neither family could be read
+			dumpErr = err
+			continue
+		}
+		dumped = true
+		for _, sk := range socks {
+			pid, ok := owners[sk.Inode]
+			if !ok || sk.State == tcpListenState {
+				continue
+			}
+			use, ok := sample.Use[pid]
+			if !ok {
+				use = &pidNetUse{}
+				sample.Use[pid] = use
+			}
+			use.Conns++
+			if !sk.HasBytes {
+				continue
+			}
+			s.prev[sk.Inode] = sk.Bytes
+			if p, ok := prev[sk.Inode]; ok && valid {
+				rate, _ := counterRate(p, sk.Bytes, elapsed)
+				use.Rate += rate
+			}
+		}
+	}
+	if !dumped {
+		return netProcSample{}, dumpErr
+	}
+	return sample, nil
+}
+
+// socketOwners maps socket inodes to the PIDs holding them, by reading the
+// /proc/<pid>/fd links. Processes whose descriptors can't be read are left
+// out.
+func socketOwners() map[uint32]int32 {
+	owners := make(map[uint32]int32)
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return owners
+	}
+	for _, p := range procs {
+		pid, err := strconv.ParseInt(p.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		dir := "/proc/" + p.Name() + "/fd/"
+		fds, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(dir + fd.Name())
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(link[len("socket:["):], "]"), 10, 32)
+			if err == nil {
+				owners[uint32(inode)] = int32(pid)
+			}
+		}
+	}
+	return owners
+}
+
+// inetSocket is one socket from a sock_diag dump
+type inetSocket struct {
+	State    uint8
+	Inode    uint32 // 0 for sockets no longer held open, such as in TIME_WAIT
+	Bytes    uint64 // Bytes acknowledged by the peer plus bytes received
+	HasBytes bool   // Whether the kernel reported Bytes
+}
+
+// dumpTCPSockets lists the TCP sockets of an address family through
+// sock_diag, with their byte counters
+func dumpTCPSockets(family uint8) ([]inetSocket, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.NETLINK_INET_DIAG)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	req := make([]byte, syscall.NLMSG_HDRLEN+inetDiagReqLen)
+	binary.NativeEndian.PutUint32(req[0:], uint32(len(req)))
+	binary.NativeEndian.PutUint16(req[4:], sockDiagByFamily)
+	binary.NativeEndian.PutUint16(req[6:], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	binary.NativeEndian.PutUint32(req[8:], 1) // Sequence number
+	body := req[syscall.NLMSG_HDRLEN:]
+	body[0] = family
+	body[1] = syscall.IPPROTO_TCP
+	body[2] = 1 << (inetDiagInfo - 1)                   // Ask for tcp_info
+	binary.NativeEndian.PutUint32(body[4:], 0xffffffff) // Every state
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	var socks []inetSocket
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return socks, nil
+			case syscall.NLMSG_ERROR:
+				if len(m.Data) >= 4 {
+					if errno := int32(binary.NativeEndian.Uint32(m.Data)); errno < 0 {
+						return nil, syscall.Errno(-errno)
+					}
+				}
+				return nil, errors.New("sock_diag request failed")
+			}
+			if len(m.Data) >= inetDiagMsgLen {
+				socks = append(socks, parseInetDiagMsg(m.Data))
+			}
+		}
+	}
+}
+
+// parseInetDiagMsg decodes a struct inet_diag_msg and the tcp_info
+// attribute that follows it
+func parseInetDiagMsg(data []byte) inetSocket {
+	sk := inetSocket{
+		State: data[1],
+		Inode: binary.NativeEndian.Uint32(data[inetDiagInodeOff:]),
+	}
+	// Attributes are a length and type followed by the value, each padded
+	// to four bytes
+	for attrs := data[inetDiagMsgLen:]; len(attrs) >= 4; {
+		size := int(binary.NativeEndian.Uint16(attrs[0:]))
+		kind := binary.NativeEndian.Uint16(attrs[2:])
+		if size < 4 || size > len(attrs) {
+			break
+		}
+		if info := attrs[4:size]; kind == inetDiagInfo && len(info) >= tcpInfoBytesReceivedOff+8 {
+			sk.Bytes = binary.NativeEndian.Uint64(info[tcpInfoBytesAckedOff:]) +
+				binary.NativeEndian.Uint64(info[tcpInfoBytesReceivedOff:])
+			sk.HasBytes = true
+		}
+		attrs = attrs[min((size+3)&^3, len(attrs)):]
+	}
+	return sk
+}
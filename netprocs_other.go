@@ -0,0 +1,35 @@
+//go:build !linux
+
+package main
+
+import "github.com/shirou/gopsutil/v3/net"
+
+// netProcSampler counts each process's TCP connections. Outside Linux
+// there is no cheap way to see how much each socket has carried, so
+// per-process bandwidth isn't measured.
+type netProcSampler struct{}
+
+func newNetProcSampler() *netProcSampler {
+	return &netProcSampler{}
+}
+
+// Sample lists TCP sockets and counts the connections of each process
+func (s *netProcSampler) Sample() (netProcSample, error) {
+	conns, err := net.Connections("tcp")
+	if err != nil {
+		return netProcSample{}, err
+	}
+	sample := netProcSample{Use: make(map[int32]*pidNetUse), Limited: socketsLimited()}
+	for _, conn := range conns {
+		if conn.Pid == 0 || conn.Status == "LISTEN" {
+			continue
+		}
+		use, ok := sample.Use[conn.Pid]
+		if !ok {
+			use = &pidNetUse{}
+			sample.Use[conn.Pid] = use
+		}
+		use.Conns++
+	}
+	return sample, nil
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// defaultExcludedInterfaces are left out of the network totals unless
+// --iface or --all-interfaces is given: loopback, and the bridges and veth
+// pairs of containers and VMs, whose traffic never leaves the machine
+var defaultExcludedInterfaces = []string{"lo", "docker*", "veth*", "br-*", "virbr*"}
+
+// netSelection chooses which interfaces the network section reports: every
+// interface matching the --iface patterns, or a single one of them picked
+// at runtime
+type netSelection struct {
+	Patterns []string // Globs from --iface, e.g. "eth*"; empty matches every interface
+	Exclude  []string // Globs left out of the totals unless picked at runtime
+	Selected string   // Interface picked at runtime, or "" for every match
+	names    []string // Matching interfaces seen in the last sample, sorted
+}
+
+// parsePatternList splits a comma-separated list of device names such as
+// "eth0,wlan*", as given to --iface or --exclude-disks, into globs,
+// rejecting malformed ones
+func parsePatternList(spec string) ([]string, error) {
+	var patterns []string
+	for _, p := range strings.Split(spec, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("bad pattern %q", p)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// matches reports whether name is one of the --iface interfaces
+func (s *netSelection) matches(name string) bool {
+	if len(s.Patterns) == 0 {
+		return true
+	}
+	for _, p := range s.Patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// excluded reports whether name is one of the Exclude interfaces
+func (s *netSelection) excluded(name string) bool {
+	for _, p := range s.Exclude {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Includes reports whether name's traffic is counted in the current
+// selection
+func (s *netSelection) Includes(name string) bool {
+	if s.Selected != "" {
+		return name == s.Selected
+	}
+	return s.matches(name) && !s.excluded(name)
+}
+
+// SetInterfaces records the interfaces in the latest sample, so Cycle knows
+// what to step through
+func (s *netSelection) SetInterfaces(counters []net.IOCountersStat) {
+	s.names = s.names[:0]
+	for _, c := range counters {
+		if s.matches(c.Name) {
+			s.names = append(s.names, c.Name)
+		}
+	}
+	sort.Strings(s.names)
+}
+
+// Cycle steps the selection from every matching interface to each one in
+// turn and back again
+func (s *netSelection) Cycle() {
+	next := 0
+	if s.Selected != "" {
+		next = sort.SearchStrings(s.names, s.Selected)
+		if next < len(s.names) && s.names[next] == s.Selected {
+			next++
+		}
+	}
+	if next < len(s.names) {
+		s.Selected = s.names[next]
+	} else {
+		s.Selected = ""
+	}
+}
+
+// Title returns the network section's title naming the selection, with the
+// link details of a single selected interface, e.g. "Network Traffic (eth0,
+// 1G)"
+func (s *netSelection) Title(links *linkCache) string {
+	switch {
+	case s.Selected != "":
+		if link := links.Get(s.Selected).String(); link != "" {
+			return fmt.Sprintf("Network Traffic (%s, %s)", s.Selected, link)
+		}
+		return fmt.Sprintf("Network Traffic (%s)", s.Selected)
+	case len(s.Patterns) > 0:
+		return fmt.Sprintf("Network Traffic (%s)", strings.Join(s.Patterns, ", "))
+	case len(s.Exclude) > 0:
+		return "Network Traffic (all but virtual)"
+	}
+	return "Network Traffic (all)"
+}
+
+// netTotals is the traffic of the selected interfaces combined
+type netTotals struct {
+	RxRate      float64 // Bytes received per second
+	TxRate      float64 // Bytes sent per second
+	Recv        uint64  // Bytes received since boot
+	Sent        uint64  // Bytes sent since boot
+	SessionRecv uint64  // Bytes received since SysGoMon started or the session was reset
+	SessionSent uint64  // Bytes sent since SysGoMon started or the session was reset
+	PktRate     float64 // Packets received and sent per second
+	ErrRate     float64 // Receive and transmit errors per second
+	DropRate    float64 // Inbound and outbound packets dropped per second
+}
+
+// HealthText renders packet, error, and drop rates, with errors and drops
+// in red whenever there are any
+func (t netTotals) HealthText() string {
+	return fmt.Sprintf("[Packets:](fg:label) %s  [Errors:](fg:label) %s  [Drops:](fg:label) %s",
+		formatEventRate(t.PktRate), alertRate(t.ErrRate), alertRate(t.DropRate))
+}
+
+// alertRate formats an event rate that should be zero, in red when it isn't
+func alertRate(rate float64) string {
+	if rate > 0 {
+		return fmt.Sprintf("[%s](fg:crit)", formatEventRate(rate))
+	}
+	return formatEventRate(rate)
+}
+
+// formatEventRate formats a per-second rate of events such as packets,
+// keeping a decimal for rare events so one error every few seconds doesn't
+// round to zero
+func formatEventRate(rate float64) string {
+	if rate > 0 && rate < 10 {
+		return fmt.Sprintf("%.1f/s", rate)
+	}
+	return formatSwitchRate(rate)
+}
+
+// netTracker turns per-interface byte counters into rates. Each interface
+// keeps its own baseline, so one that appears mid-session, such as a VPN
+// coming up, starts from zero instead of its whole history showing up as a
+// spike.
+type netTracker struct {
+	Interfaces []ifaceTraffic  // Traffic of each --iface interface in the last sample, busiest first
+	Active     map[string]bool // Interfaces that have carried traffic this session
+	Found      int             // Interfaces in the last sample, matched by --iface or not
+
+	prev    map[string]net.IOCountersStat        // Counters at the last sample, by interface name
+	last    time.Time                            // When the last sample was taken
+	session map[string]sessionBytes              // Traffic this session, by interface name
+	read    func() ([]net.IOCountersStat, error) // Reads the per-interface counters
+}
+
+// sessionBytes is the traffic an interface has carried this session
+type sessionBytes struct {
+	Recv, Sent uint64
+}
+
+func newNetTracker() *netTracker {
+	return &netTracker{
+		Active:  make(map[string]bool),
+		prev:    make(map[string]net.IOCountersStat),
+		session: make(map[string]sessionBytes),
+		read: func() ([]net.IOCountersStat, error) {
+			return net.IOCounters(true)
+		},
+	}
+}
+
+// ResetSession zeroes the session totals, to measure a transfer from now
+func (t *netTracker) ResetSession() {
+	clear(t.session)
+}
+
+// Sample reads the interface counters, records the interfaces in sel, and
+// passes the counters to Update
+func (t *netTracker) Sample(sel *netSelection) (netTotals, error) {
+	counters, err := t.read()
+	if err != nil {
+		return netTotals{}, err
+	}
+	sel.SetInterfaces(counters)
+	return t.Update(counters, sel), nil
+}
+
+// Update takes a new sample of per-interface counters and returns the
+// combined traffic of the interfaces sel includes, recording each
+// interface's own traffic in Interfaces. Interfaces without a baseline, or
+// whose counters went backwards, add to the totals but not the rates, and
+// so does every interface after a suspend; their baselines start over from
+// this sample.
+func (t *netTracker) Update(counters []net.IOCountersStat, sel *netSelection) netTotals {
+	now := time.Now()
+	elapsed, valid := sampleElapsed(t.last, now)
+	seconds := elapsed.Seconds()
+	prev := t.prev
+	t.prev = make(map[string]net.IOCountersStat, len(counters))
+	t.last = now
+	t.Interfaces = t.Interfaces[:0]
+	t.Found = len(counters)
+
+	var totals netTotals
+	for _, c := range counters {
+		t.prev[c.Name] = c
+		if !sel.matches(c.Name) {
+			continue
+		}
+		it := ifaceTraffic{Name: c.Name, Total: c.BytesRecv + c.BytesSent}
+		p, ok := prev[c.Name]
+		ok = ok && !countersReset(c, p)
+		// Traffic across a suspend is real even though its rate isn't known
+		session := t.session[c.Name]
+		if ok {
+			session.Recv += c.BytesRecv - p.BytesRecv
+			session.Sent += c.BytesSent - p.BytesSent
+			t.session[c.Name] = session
+		}
+		ok = ok && valid
+		if ok {
+			it.RxRate, _ = counterRate(p.BytesRecv, c.BytesRecv, elapsed)
+			it.TxRate, _ = counterRate(p.BytesSent, c.BytesSent, elapsed)
+		}
+		if it.Rate() > 0 {
+			t.Active[c.Name] = true
+		}
+		it.Counted = sel.Includes(c.Name)
+		t.Interfaces = append(t.Interfaces, it)
+
+		if !it.Counted {
+			continue
+		}
+		totals.Recv += c.BytesRecv
+		totals.Sent += c.BytesSent
+		totals.SessionRecv += session.Recv
+		totals.SessionSent += session.Sent
+		totals.RxRate += it.RxRate
+		totals.TxRate += it.TxRate
+		if ok {
+			totals.PktRate += float64(c.PacketsRecv+c.PacketsSent-p.PacketsRecv-p.PacketsSent) / seconds
+			totals.ErrRate += float64(c.Errin+c.Errout-p.Errin-p.Errout) / seconds
+			totals.DropRate += float64(c.Dropin+c.Dropout-p.Dropin-p.Dropout) / seconds
+		}
+	}
+	sort.SliceStable(t.Interfaces, func(i, j int) bool {
+		if a, b := t.Interfaces[i].Rate(), t.Interfaces[j].Rate(); a != b {
+			return a > b
+		}
+		return t.Interfaces[i].Name < t.Interfaces[j].Name
+	})
+	return totals
+}
+
+// Text renders traffic, the totals from the last sample, for the network
+// section. A fresh container or network namespace may have no interfaces at
+// all, which is said outright rather than shown as an idle interface.
+func (t *netTracker) Text(traffic netTotals, unit NetUnit, conns string) string {
+	if t.Found == 0 {
+		return "[no network interfaces](fg:warn)"
+	}
+	return fmt.Sprintf(
+		"[In:  ](fg:rx) %13s  [Out: ](fg:tx) %13s  [Session:](fg:label) %s ↓ / %s ↑ (boot: %s / %s)\n%s\n%s",
+		unit.Format(traffic.RxRate),
+		unit.Format(traffic.TxRate),
+		formatBytes(traffic.SessionRecv),
+		formatBytes(traffic.SessionSent),
+		formatBytes(traffic.Recv),
+		formatBytes(traffic.Sent),
+		traffic.HealthText(),
+		conns,
+	)
+}
+
+// countersReset reports whether any of an interface's counters went
+// backwards since the previous sample, as they do when a driver is reloaded
+func countersReset(cur, prev net.IOCountersStat) bool {
+	return cur.BytesRecv < prev.BytesRecv || cur.BytesSent < prev.BytesSent ||
+		cur.PacketsRecv < prev.PacketsRecv || cur.PacketsSent < prev.PacketsSent ||
+		cur.Errin < prev.Errin || cur.Errout < prev.Errout ||
+		cur.Dropin < prev.Dropin || cur.Dropout < prev.Dropout
+}
+
+// ifaceTraffic is one interface's traffic as of the last sample
+type ifaceTraffic struct {
+	Name    string
+	RxRate  float64 // Bytes received per second
+	TxRate  float64 // Bytes sent per second
+	Total   uint64  // Bytes received and sent since boot
+	Counted bool    // Whether the interface is counted in the network totals
+}
+
+// Rate returns the interface's combined throughput
+func (t ifaceTraffic) Rate() float64 {
+	return t.RxRate + t.TxRate
+}
+
+// Size of the per-interface table beside the network graph, which is only
+// shown on terminals wide enough to spare the columns
+const (
+	ifaceTableWidth        = 59
+	minIfaceTableTermWidth = 120
+	ifaceNameWidth         = 10
+)
+
+// InterfaceTable lists each interface with its rates, total traffic, link
+// state, and link speed or wireless signal, busiest first. Interfaces left
+// out of the network totals are greyed.
+type InterfaceTable struct {
+	*widgets.Paragraph
+	ShowIdle bool       // List interfaces that haven't carried traffic this session
+	Unit     NetUnit    // Unit the rates are shown in
+	Visible  bool       // Whether the terminal is wide enough to show the table
+	Links    *linkCache // Link details of each interface
+}
+
+func createInterfaceTable() *InterfaceTable {
+	t := &InterfaceTable{Paragraph: widgets.NewParagraph()}
+	t.Title = "Interfaces"
+	t.Border = true
+	t.WrapText = false
+	t.TitleStyle.Fg = theme.Title
+	return t
+}
+
+// Update rebuilds the table from the latest per-interface traffic
+func (t *InterfaceTable) Update(traffic []ifaceTraffic, active map[string]bool) {
+	up := interfacesUp()
+	lines := []string{fmt.Sprintf("[%-*s %11s %11s %9s %4s %6s](fg:label)", ifaceNameWidth, "Iface", "In/s", "Out/s", "Total", "Link", "Speed")}
+	hidden := 0
+	for _, it := range traffic {
+		if !t.ShowIdle && !active[it.Name] {
+			hidden++
+			continue
+		}
+		state, color := "down", "crit"
+		if isUp, ok := up[it.Name]; !ok {
+			state, color = "?", "text"
+		} else if isUp {
+			state, color = "up", "ok"
+		}
+		name := truncateToWidth(it.Name, ifaceNameWidth)
+		rx, tx, total, link := t.Unit.Format(it.RxRate), t.Unit.Format(it.TxRate), formatBytes(it.Total), t.Links.Get(it.Name).Short()
+		if !it.Counted {
+			lines = append(lines, fmt.Sprintf("[%-*s %11s %11s %9s %4s %6s](fg:dim)",
+				ifaceNameWidth, name, rx, tx, total, state, link))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%-*s %11s %11s %9s [%4s](fg:%s) %6s",
+			ifaceNameWidth, name, rx, tx, total, state, color, link))
+	}
+	t.Title = "Interfaces"
+	if hidden > 0 {
+		t.Title = fmt.Sprintf("Interfaces (%d idle hidden)", hidden)
+	}
+	t.Text = strings.Join(lines, "\n")
+}
+
+// interfacesUp returns whether each interface's link is up, by name. It is
+// empty when the platform can't list interfaces.
+func interfacesUp() map[string]bool {
+	up := make(map[string]bool)
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return up
+	}
+	for _, iface := range ifaces {
+		up[iface.Name] = slices.Contains(iface.Flags, "up")
+	}
+	return up
+}
+
+// NetUnit selects whether network rates are shown in bits or bytes
+type NetUnit int
+
+const (
+	NetBits  NetUnit = iota // Kbps, Mbps, and Gbps, graphed in Mbps
+	NetBytes                // KB/s, MB/s, and GB/s like the disk section, graphed in MB/s
+	netUnitCount
+)
+
+// netUnitNames are the --units values, indexed by NetUnit
+var netUnitNames = []string{"bits", "bytes"}
+
+// parseNetUnit returns the NetUnit called name
+func parseNetUnit(name string) (NetUnit, error) {
+	for i, n := range netUnitNames {
+		if strings.EqualFold(name, n) {
+			return NetUnit(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown units %q (valid units: %s)", name, strings.Join(netUnitNames, ", "))
+}
+
+// Graph converts a rate in bytes per second to the graph's unit
+func (u NetUnit) Graph(bps float64) float64 {
+	if u == NetBytes {
+		return bps / (1024 * 1024)
+	}
+	return bps * 8 / 1000000
+}
+
+// GraphUnit names the unit the graph is plotted in
+func (u NetUnit) GraphUnit() string {
+	if u == NetBytes {
+		return "MB/s"
+	}
+	return "Mbps"
+}
+
+// Format formats a rate in bytes per second, scaled to a readable unit
+func (u NetUnit) Format(bps float64) string {
+	if u == NetBytes {
+		return formatRate(bps)
+	}
+	return formatBitRate(bps)
+}
+
+// formatBitRate formats a rate in bytes per second as bits per second,
+// scaled to Kbps, Mbps, or Gbps
+func formatBitRate(bps float64) string {
+	bits := bps * 8
+	switch {
+	case bits >= 1e9:
+		return fmt.Sprintf("%.2f Gbps", bits/1e9)
+	case bits >= 1e6:
+		return fmt.Sprintf("%.2f Mbps", bits/1e6)
+	}
+	return fmt.Sprintf("%.2f Kbps", bits/1e3)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// TestNetTrackerNoInterfaces is a regression test for a network namespace
+// with no interfaces, which showed zero rates as if an idle one were there
+func TestNetTrackerNoInterfaces(t *testing.T) {
+	tracker := newNetTracker()
+	tracker.read = func() ([]net.IOCountersStat, error) {
+		return nil, nil
+	}
+	sel := &netSelection{}
+
+	for pass := 0; pass < 2; pass++ {
+		traffic, err := tracker.Sample(sel)
+		if err != nil {
+			t.Fatalf("pass %d: %v", pass, err)
+		}
+		if traffic != (netTotals{}) {
+			t.Errorf("pass %d: totals = %+v, want zero", pass, traffic)
+		}
+		if len(tracker.Interfaces) != 0 || len(sel.names) != 0 {
+			t.Errorf("pass %d: interfaces %v, selection %v, want none", pass, tracker.Interfaces, sel.names)
+		}
+		if text := tracker.Text(traffic, NetBits, ""); !strings.Contains(text, "no network interfaces") {
+			t.Errorf("pass %d: text = %q, want it to say there are no interfaces", pass, text)
+		}
+	}
+
+	// An interface appearing later is reported normally
+	tracker.read = func() ([]net.IOCountersStat, error) {
+		return []net.IOCountersStat{{Name: "eth0", BytesRecv: 1000, BytesSent: 500}}, nil
+	}
+	traffic, err := tracker.Sample(sel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := tracker.Text(traffic, NetBits, ""); strings.Contains(text, "no network interfaces") {
+		t.Errorf("text = %q with eth0 present", text)
+	}
+	if traffic.Recv != 1000 || traffic.Sent != 500 {
+		t.Errorf("totals = %+v, want 1000 received and 500 sent", traffic)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// oomScoreWarning is the OOM score above which a process is highlighted as
+// a likely first victim of the kernel's OOM killer
+const oomScoreWarning = 900
+
+// processOOMScore reads the kernel's current OOM badness score for pid from
+// /proc/PID/oom_score, which gopsutil doesn't expose. It returns -1 on other
+// platforms and when the file can't be read.
+func processOOMScore(pid int32) int32 {
+	if runtime.GOOS != "linux" {
+		return -1
+	}
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/oom_score", pid))
+	if err != nil {
+		return -1
+	}
+	score, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil {
+		return -1
+	}
+	return int32(score)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+)
+
+const (
+	pingInterval    = time.Second            // How often a probe is sent
+	pingTimeout     = 900 * time.Millisecond // How long a probe waits before counting as lost
+	defaultPingPort = "443"                  // Port connected to when ICMP isn't available
+	pingPanelWidth  = 42                     // Width of the panel beside the network stats
+)
+
+// pingTarget is the host given to --ping, and the port to connect to when
+// probing over TCP
+type pingTarget struct {
+	Host string
+	Port string // Set when given as host:port, which always probes over TCP
+}
+
+// parsePingTarget parses a --ping value such as "example.com",
+// "10.0.0.1:22", or "[::1]:80"
+func parsePingTarget(spec string) (pingTarget, error) {
+	spec = strings.TrimSpace(spec)
+	if host, port, err := net.SplitHostPort(spec); err == nil {
+		if host == "" || port == "" {
+			return pingTarget{}, errors.New("missing host or port")
+		}
+		return pingTarget{Host: host, Port: port}, nil
+	}
+	if spec == "" {
+		return pingTarget{}, errors.New("missing host")
+	}
+	return pingTarget{Host: strings.Trim(spec, "[]")}, nil
+}
+
+// String returns the target as shown in the panel title
+func (t pingTarget) String() string {
+	if t.Port != "" {
+		return net.JoinHostPort(t.Host, t.Port)
+	}
+	return t.Host
+}
+
+// pingResult is the outcome of one probe
+type pingResult struct {
+	RTT  time.Duration
+	Lost bool
+	Err  error // Why the probe couldn't be sent, such as a failed lookup
+}
+
+// Pinger probes a host every pingInterval on its own goroutine, publishing
+// each result on Results. It sends ICMP echo requests when it may open a raw
+// socket, which usually needs root, and otherwise times a TCP connect.
+type Pinger struct {
+	Target  pingTarget
+	Method  string          // "icmp" or "tcp"
+	Results chan pingResult // Outcome of each probe
+	conn    net.PacketConn  // Raw ICMP socket, or nil when probing over TCP
+	ctx     context.Context // Cancelled by Stop, aborting a probe in flight
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup // Tracks the running goroutine
+}
+
+func NewPinger(target pingTarget) *Pinger {
+	p := &Pinger{
+		Target:  target,
+		Method:  "tcp",
+		Results: make(chan pingResult, 8),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	if target.Port == "" {
+		if conn, err := net.ListenPacket("ip4:icmp", "0.0.0.0"); err == nil {
+			p.conn = conn
+			p.Method = "icmp"
+		}
+	}
+	return p
+}
+
+// Start launches the probe goroutine
+func (p *Pinger) Start() {
+	p.wg.Add(1)
+	go p.run()
+}
+
+// Stop ends the probe goroutine, abandoning any probe in flight, and waits
+// for it to exit
+func (p *Pinger) Stop() {
+	p.cancel()
+	if p.conn != nil {
+		// Unblocks a read waiting for a reply
+		p.conn.Close()
+	}
+	p.wg.Wait()
+}
+
+func (p *Pinger) run() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for seq := 0; ; seq++ {
+		var result pingResult
+		if p.conn != nil {
+			result = p.probeICMP(uint16(seq))
+		} else {
+			result = p.probeTCP()
+		}
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		// Drop the result rather than wait if the UI has fallen behind
+		select {
+		case p.Results <- result:
+		default:
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeTCP times a connection to the target. A refused connection still
+// means the host answered, so it counts as a reply.
+func (p *Pinger) probeTCP() pingResult {
+	port := p.Target.Port
+	if port == "" {
+		port = defaultPingPort
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, pingTimeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(p.Target.Host, port))
+	rtt := time.Since(start)
+	if err == nil {
+		conn.Close()
+		return pingResult{RTT: rtt}
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return pingResult{RTT: rtt}
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return pingResult{Lost: true, Err: err}
+	}
+	return pingResult{Lost: true}
+}
+
+// probeICMP sends an echo request and waits for its reply
+func (p *Pinger) probeICMP(seq uint16) pingResult {
+	addr, err := net.ResolveIPAddr("ip4", p.Target.Host)
+	if err != nil {
+		return pingResult{Lost: true, Err: err}
+	}
+
+	id := uint16(os.Getpid())
+	msg := make([]byte, 16)
+	msg[0] = 8 // Echo request
+	binary.BigEndian.PutUint16(msg[4:], id)
+	binary.BigEndian.PutUint16(msg[6:], seq)
+	binary.BigEndian.PutUint16(msg[2:], icmpChecksum(msg))
+
+	start := time.Now()
+	p.conn.SetDeadline(start.Add(pingTimeout))
+	if _, err := p.conn.WriteTo(msg, addr); err != nil {
+		return pingResult{Lost: true, Err: err}
+	}
+
+	// The raw socket sees every ICMP packet the host receives, so skip
+	// anything that isn't the reply to this request
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := p.conn.ReadFrom(buf)
+		if err != nil {
+			return pingResult{Lost: true}
+		}
+		if n >= 8 && buf[0] == 0 && binary.BigEndian.Uint16(buf[4:]) == id && binary.BigEndian.Uint16(buf[6:]) == seq {
+			return pingResult{RTT: time.Since(start)}
+		}
+	}
+}
+
+// icmpChecksum returns the Internet checksum of an ICMP message
+func icmpChecksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// PingPanel shows the recent round-trip times to the --ping host as a
+// sparkline, with lost probes marked in red, and the current and average
+// RTT and loss over the sparkline's window
+type PingPanel struct {
+	*widgets.Paragraph
+	history []float64 // RTT of each probe in milliseconds, or -1 when lost, oldest first
+	lastErr error     // Why the last probe couldn't be sent, if it couldn't
+}
+
+func createPingPanel(p *Pinger) *PingPanel {
+	pp := &PingPanel{Paragraph: widgets.NewParagraph()}
+	pp.Title = fmt.Sprintf("Ping %s (%s)", p.Target, p.Method)
+	pp.Border = true
+	pp.WrapText = false
+	pp.TitleStyle.Fg = theme.Title
+	pp.Text = "[Waiting for the first reply...](fg:warn)"
+	return pp
+}
+
+// Add records a probe's result and rebuilds the text
+func (pp *PingPanel) Add(r pingResult) {
+	rtt := -1.0
+	if !r.Lost {
+		rtt = float64(r.RTT) / float64(time.Millisecond)
+	}
+	pp.history = append(pp.history, rtt)
+	if width := pp.Inner.Dx(); width > 0 && len(pp.history) > width {
+		pp.history = pp.history[len(pp.history)-width:]
+	}
+	pp.lastErr = r.Err
+	pp.refresh()
+}
+
+func (pp *PingPanel) refresh() {
+	var sum, peak float64
+	replies := 0
+	for _, rtt := range pp.history {
+		if rtt >= 0 {
+			sum += rtt
+			peak = max(peak, rtt)
+			replies++
+		}
+	}
+
+	// Sparkline, with runs of replies and losses drawn in their own colors
+	var spark strings.Builder
+	for i := 0; i < len(pp.history); {
+		j := i
+		lost := pp.history[i] < 0
+		for j < len(pp.history) && (pp.history[j] < 0) == lost {
+			j++
+		}
+		if lost {
+			fmt.Fprintf(&spark, "[%s](fg:crit)", strings.Repeat("▁", j-i))
+		} else {
+			fmt.Fprintf(&spark, "[%s](fg:ok)", sparkline(pp.history[i:j], peak))
+		}
+		i = j
+	}
+
+	now := "[lost](fg:crit)"
+	if last := pp.history[len(pp.history)-1]; last >= 0 {
+		now = formatRTT(last)
+	}
+	var dnsErr *net.DNSError
+	if errors.As(pp.lastErr, &dnsErr) {
+		now = "[lookup failed](fg:crit)"
+	} else if pp.lastErr != nil {
+		now = "[send failed](fg:crit)"
+	}
+	avg := "-"
+	if replies > 0 {
+		avg = formatRTT(sum / float64(replies))
+	}
+	loss := 100 * float64(len(pp.history)-replies) / float64(len(pp.history))
+	lossText := fmt.Sprintf("%.1f%%", loss)
+	if loss > 0 {
+		lossText = fmt.Sprintf("[%s](fg:crit)", lossText)
+	}
+
+	pp.Text = fmt.Sprintf("%s\n[Now:](fg:label) %s  [Avg:](fg:label) %s\n[Loss:](fg:label) %s of %d",
+		spark.String(), now, avg, lossText, len(pp.history))
+}
+
+// formatRTT formats a round-trip time in milliseconds
+func formatRTT(ms float64) string {
+	if ms < 10 {
+		return fmt.Sprintf("%.2f ms", ms)
+	}
+	return fmt.Sprintf("%.0f ms", ms)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// defaultPSIThresholds apply unless --psi-warn or --psi-crit say otherwise.
+// Even a few percent of time stalled on memory is noticeable, so these sit
+// well below the utilization thresholds.
+var defaultPSIThresholds = usageThresholds{Warn: 10, Crit: 30}
+
+// pressureStat holds the 10-second averages of a Linux pressure stall
+// information (PSI) file: the percentage of time at least one task was
+// stalled on the resource ("some"), and the percentage all of them were
+// ("full")
+type pressureStat struct {
+	Some float64
+	Full float64
+}
+
+// readMemoryPressure reads /proc/pressure/memory. It reports false on
+// platforms and kernels without PSI.
+func readMemoryPressure() (pressureStat, bool) {
+	if runtime.GOOS != "linux" {
+		return pressureStat{}, false
+	}
+	f, err := os.Open("/proc/pressure/memory")
+	if err != nil {
+		return pressureStat{}, false
+	}
+	defer f.Close()
+	return parsePressure(f)
+}
+
+// parsePressure parses PSI lines such as
+//
+//	some avg10=1.53 avg60=0.87 avg300=0.21 total=1234567
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//
+// reporting false unless a "some" line was found
+func parsePressure(r io.Reader) (pressureStat, bool) {
+	var stat pressureStat
+	var haveSome bool
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		var avg10 float64
+		var ok bool
+		for _, field := range fields[1:] {
+			if value, found := strings.CutPrefix(field, "avg10="); found {
+				var err error
+				avg10, err = strconv.ParseFloat(value, 64)
+				ok = err == nil
+			}
+		}
+		if !ok {
+			continue
+		}
+		switch fields[0] {
+		case "some":
+			stat.Some, haveSome = avg10, true
+		case "full":
+			stat.Full = avg10
+		}
+	}
+	return stat, haveSome
+}
+
+// pressureText renders memory pressure as e.g. "PSI: some 1.5% full 0.0%",
+// coloring each figure by thresholds
+func pressureText(stat pressureStat, thresholds usageThresholds) string {
+	return fmt.Sprintf("[PSI:](fg:label) some [%.1f%%](fg:%s) full [%.1f%%](fg:%s)",
+		stat.Some, thresholds.Color(stat.Some), stat.Full, thresholds.Color(stat.Full))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePressure(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  pressureStat
+		ok    bool
+	}{
+		{
+			name: "some and full",
+			input: "some avg10=1.53 avg60=0.87 avg300=0.21 total=1234567\n" +
+				"full avg10=0.42 avg60=0.10 avg300=0.02 total=98765\n",
+			want: pressureStat{Some: 1.53, Full: 0.42},
+			ok:   true,
+		},
+		{
+			// As /proc/pressure/cpu reads before Linux 5.13
+			name:  "some only",
+			input: "some avg10=7.25 avg60=3.00 avg300=1.00 total=42\n",
+			want:  pressureStat{Some: 7.25},
+			ok:    true,
+		},
+		{
+			name: "malformed some avg10",
+			input: "some avg10=abc avg60=0.87 avg300=0.21 total=1234567\n" +
+				"full avg10=0.42 avg60=0.10 avg300=0.02 total=98765\n",
+			want: pressureStat{Full: 0.42},
+			ok:   false,
+		},
+		{
+			name: "malformed full avg10",
+			input: "some avg10=1.53 avg60=0.87 avg300=0.21 total=1234567\n" +
+				"full avg10= avg60=0.10 avg300=0.02 total=98765\n",
+			want: pressureStat{Some: 1.53},
+			ok:   true,
+		},
+		{
+			name:  "missing avg10",
+			input: "some avg60=0.87 avg300=0.21 total=1234567\n",
+			ok:    false,
+		},
+		{
+			name:  "empty",
+			input: "",
+			ok:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parsePressure(strings.NewReader(tt.input))
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parsePressure() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
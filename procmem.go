@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// memoryViewColumns is the process table layout the memory view switches
+// to: resident, shared, and swapped memory up front, with CPU% pushed back
+const memoryViewColumns = "pid,user,name,rss,shared,swap,cpu,command"
+
+// processSharedSwap reads how much of pid's resident memory is shared with
+// other processes, from /proc/PID/statm, and how much has been swapped out,
+// from the VmSwap line of /proc/PID/status, neither of which gopsutil
+// exposes on every platform. Each is -1 on other platforms and when it
+// can't be read; kernel threads have no VmSwap line at all.
+func processSharedSwap(pid int32) (shared, swap int64) {
+	shared, swap = -1, -1
+	if runtime.GOOS != "linux" {
+		return shared, swap
+	}
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid)); err == nil {
+		// size resident shared text lib data dt, in pages
+		if fields := strings.Fields(string(data)); len(fields) > 2 {
+			if pages, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+				shared = pages * int64(os.Getpagesize())
+			}
+		}
+	}
+
+	f, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return shared, swap
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// VmSwap:	    1234 kB
+		value, found := strings.CutPrefix(scanner.Text(), "VmSwap:")
+		if !found {
+			continue
+		}
+		if fields := strings.Fields(value); len(fields) > 0 {
+			if kb, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+				swap = kb * 1024
+			}
+		}
+		break
+	}
+	return shared, swap
+}
+
+// formatSize formats a byte count that may be unknown, showing "-" when
+// it is negative
+func formatSize(bytes int64) string {
+	if bytes < 0 {
+		return "-"
+	}
+	return formatBytes(uint64(bytes))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// clockTicks is the kernel's USER_HZ, the unit of the times in
+// /proc/PID/stat. It is 100 on every mainstream architecture, which gopsutil
+// assumes too.
+const clockTicks = 100
+
+// processStat holds the fields a collection pass needs for every process
+type processStat struct {
+	PPID    int32   // Parent PID, read every pass since orphans are re-parented
+	CPU     float64 // Cumulative user and system CPU seconds
+	Started int64   // Start time in clock ticks since boot, or 0 when unknown
+}
+
+// readProcessStat reads pid's parent PID and CPU time. On Linux both come
+// from a single read of /proc/PID/stat, which also gives the start time for
+// free, where gopsutil would read the file once for each. Other platforms go
+// through gopsutil and leave Started at 0.
+func readProcessStat(pid int32) (processStat, error) {
+	if runtime.GOOS != "linux" {
+		p := &process.Process{Pid: pid}
+		times, err := p.Times()
+		if err != nil {
+			return processStat{}, err
+		}
+		stat := processStat{CPU: times.User + times.System}
+		if ppid, err := p.Ppid(); err == nil {
+			stat.PPID = ppid
+		}
+		return stat, nil
+	}
+
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return processStat{}, err
+	}
+	// The command name is in parentheses and can contain spaces, so count
+	// fields from after it. fields[0] is the state, the 3rd field overall.
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return processStat{}, fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 20 {
+		return processStat{}, fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	ppid, err := strconv.ParseInt(fields[1], 10, 32)
+	if err != nil {
+		return processStat{}, err
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return processStat{}, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return processStat{}, err
+	}
+	started, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return processStat{}, err
+	}
+	return processStat{
+		PPID:    int32(ppid),
+		CPU:     float64(utime+stime) / clockTicks,
+		Started: started,
+	}, nil
+}
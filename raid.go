@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// procMDStat lists Linux software RAID (md) arrays. Each array is a line
+// naming it, its state, level, and members, followed by indented lines
+// with its size, which members are up, and any resync in progress:
+//
+//	md0 : active raid5 sdd1[3] sdc1[1] sdb1[0]
+//	      2095104 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [UU_]
+//	      [==>..................]  recovery = 12.6% (132096/1047552) finish=0.4min speed=33024K/sec
+const procMDStat = "/proc/mdstat"
+
+var (
+	// mdMemberCounts matches the "[3/2]" of an array's expected and
+	// working members
+	mdMemberCounts = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+	// mdProgress matches a resync in progress, e.g. "recovery = 12.6%"
+	// followed by "finish=0.4min"
+	mdProgress = regexp.MustCompile(`(resync|recovery|reshape|check)\s*=\s*([\d.]+)%.*?finish=([\d.]+)min`)
+	// mdPending matches a resync waiting its turn, e.g. "resync=DELAYED"
+	mdPending = regexp.MustCompile(`(resync|recovery|reshape|check)\s*=\s*(DELAYED|PENDING)`)
+)
+
+// mdArray is one software RAID array
+type mdArray struct {
+	Name     string        // e.g. "md0"
+	State    string        // "active", "inactive", or with a qualifier such as "active (auto-read-only)"
+	Level    string        // e.g. "raid1", or "" for an inactive array
+	Members  int           // Member devices listed, spares and failed ones included
+	Failed   int           // Members marked failed
+	Spares   int           // Members held as spares
+	Disks    int           // Members the array should have, or 0 when not reported
+	Working  int           // Members in use
+	Sync     string        // Resync in progress: "resync", "recovery", "reshape", "check", or ""
+	Progress float64       // Percentage of Sync done
+	Pending  bool          // Sync is waiting for another array's to finish
+	Finish   time.Duration // Estimated time until Sync finishes
+}
+
+// Degraded reports whether the array is missing members or has failed ones
+func (a mdArray) Degraded() bool {
+	return a.Working < a.Disks || a.Failed > 0
+}
+
+// Rebuilding reports whether the array is rewriting redundancy. A check
+// only reads, so it doesn't count.
+func (a mdArray) Rebuilding() bool {
+	return a.Sync != "" && a.Sync != "check"
+}
+
+// Status describes the array's health in a few words, e.g. "degraded" or
+// "recovery 12.6%, ~3m left"
+func (a mdArray) Status() string {
+	switch {
+	case a.Sync != "" && a.Pending:
+		return a.Sync + " pending"
+	case a.Sync != "":
+		return fmt.Sprintf("%s %.1f%%, ~%s left", a.Sync, a.Progress, formatFinish(a.Finish))
+	case a.Degraded():
+		return "degraded"
+	case !strings.HasPrefix(a.State, "active"):
+		return a.State
+	}
+	return "healthy"
+}
+
+// formatFinish formats a resync's remaining time, e.g. "40s", "12m", or
+// "3h20m"
+func formatFinish(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// parseMDStat parses the arrays listed in /proc/mdstat
+func parseMDStat(r io.Reader) ([]mdArray, error) {
+	var arrays []mdArray
+	var current *mdArray
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			current = nil
+			continue
+		}
+
+		// An array's first line starts unindented with its name
+		if name, rest, ok := strings.Cut(line, " : "); ok && !strings.HasPrefix(line, " ") && strings.HasPrefix(name, "md") {
+			arrays = append(arrays, parseMDArrayLine(name, rest))
+			current = &arrays[len(arrays)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if m := mdMemberCounts.FindStringSubmatch(trimmed); m != nil && current.Disks == 0 {
+			current.Disks, _ = strconv.Atoi(m[1])
+			current.Working, _ = strconv.Atoi(m[2])
+		}
+		if m := mdProgress.FindStringSubmatch(trimmed); m != nil {
+			current.Sync = m[1]
+			current.Progress, _ = strconv.ParseFloat(m[2], 64)
+			if minutes, err := strconv.ParseFloat(m[3], 64); err == nil {
+				current.Finish = time.Duration(minutes * float64(time.Minute))
+			}
+		} else if m := mdPending.FindStringSubmatch(trimmed); m != nil {
+			current.Sync = m[1]
+			current.Pending = true
+		}
+	}
+	return arrays, scanner.Err()
+}
+
+// parseMDArrayLine parses the rest of an array's first line, e.g.
+// "active raid1 sdb1[1] sda1[0](F)"
+func parseMDArrayLine(name, rest string) mdArray {
+	a := mdArray{Name: name}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return a
+	}
+	a.State = fields[0]
+	fields = fields[1:]
+	// A qualifier such as "(auto-read-only)" follows the state
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "(") {
+		a.State += " " + fields[0]
+		fields = fields[1:]
+	}
+	// Inactive arrays list no level
+	if len(fields) > 0 && !strings.Contains(fields[0], "[") {
+		a.Level = fields[0]
+		fields = fields[1:]
+	}
+	for _, member := range fields {
+		if !strings.Contains(member, "[") {
+			continue
+		}
+		a.Members++
+		switch {
+		case strings.HasSuffix(member, "(F)"):
+			a.Failed++
+		case strings.HasSuffix(member, "(S)"):
+			a.Spares++
+		}
+	}
+	// Without the counts line, every member that isn't a spare or failed
+	// is taken to be working
+	a.Working = a.Members - a.Failed - a.Spares
+	return a
+}
+
+// readMDStat reads the arrays from /proc/mdstat, which only exists on Linux
+// with the md driver loaded
+func readMDStat() ([]mdArray, error) {
+	f, err := os.Open(procMDStat)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseMDStat(f)
+}
+
+// RAIDPanel lists the software RAID arrays, in red while any is degraded
+// or rebuilding. It is drawn over the disk section while open, and can't
+// be opened on systems without arrays.
+type RAIDPanel struct {
+	*widgets.Paragraph
+	Active bool
+	Arrays []mdArray // Arrays at the last Update
+}
+
+func createRAIDPanel() *RAIDPanel {
+	p := &RAIDPanel{Paragraph: widgets.NewParagraph()}
+	p.Border = true
+	p.WrapText = false
+	p.TitleStyle.Fg = theme.Title
+	p.BorderStyle.Fg = theme.Panel
+	return p
+}
+
+// Open shows the panel
+func (p *RAIDPanel) Open() {
+	p.Active = true
+}
+
+// Close hides the panel
+func (p *RAIDPanel) Close() {
+	p.Active = false
+}
+
+// Update re-reads the arrays and refreshes the panel's text
+func (p *RAIDPanel) Update() {
+	arrays, err := readMDStat()
+	if err != nil {
+		arrays = nil
+	}
+	p.Arrays = arrays
+
+	p.Title = fmt.Sprintf("Software RAID: %d arrays (A to close)", len(arrays))
+	p.BorderStyle.Fg = theme.Panel
+	if p.Troubled() != "" {
+		p.BorderStyle.Fg = theme.Crit
+	}
+	lines := []string{fmt.Sprintf("[%-8s %-10s %-7s %-26s %s](fg:label)", "Array", "Level", "Members", "State", "Status")}
+	for _, a := range arrays {
+		members := fmt.Sprintf("%d/%d", a.Working, a.Disks)
+		if a.Disks == 0 {
+			members = fmt.Sprint(a.Working)
+		}
+		status := a.Status()
+		if a.Degraded() || a.Rebuilding() {
+			status = fmt.Sprintf("[%s](fg:crit)", status)
+		}
+		extra := ""
+		if a.Failed > 0 {
+			extra += fmt.Sprintf(", %d failed", a.Failed)
+		}
+		if a.Spares > 0 {
+			extra += fmt.Sprintf(", %d spare", a.Spares)
+		}
+		lines = append(lines, fmt.Sprintf("%-8s %-10s %-7s %-26s %s%s",
+			a.Name, a.Level, members, truncateToWidth(a.State, 26), status, extra))
+	}
+	p.Text = strings.Join(lines, "\n")
+}
+
+// Troubled names the first array that is degraded or rebuilding along
+// with its status, e.g. "md0 degraded", or returns "" when all are fine
+func (p *RAIDPanel) Troubled() string {
+	for _, a := range p.Arrays {
+		if a.Degraded() || a.Rebuilding() {
+			return a.Name + " " + a.Status()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMDStat(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   []mdArray
+		status []string // Status of each array
+	}{
+		{
+			name: "healthy",
+			input: `Personalities : [raid1] [linear] [multipath] [raid0] [raid6] [raid5] [raid4] [raid10]
+md0 : active raid1 sdb1[1] sda1[0]
+      976629760 blocks super 1.2 [2/2] [UU]
+      bitmap: 1/8 pages [4KB], 65536KB chunk
+
+unused devices: <none>
+`,
+			want: []mdArray{{
+				Name: "md0", State: "active", Level: "raid1",
+				Members: 2, Disks: 2, Working: 2,
+			}},
+			status: []string{"healthy"},
+		},
+		{
+			name: "degraded",
+			input: `Personalities : [raid1]
+md1 : active raid1 sdd1[2](F) sdc1[0]
+      488254464 blocks super 1.2 [2/1] [U_]
+
+unused devices: <none>
+`,
+			want: []mdArray{{
+				Name: "md1", State: "active", Level: "raid1",
+				Members: 2, Failed: 1, Disks: 2, Working: 1,
+			}},
+			status: []string{"degraded"},
+		},
+		{
+			name: "rebuilding",
+			input: `Personalities : [raid6] [raid5] [raid4]
+md0 : active raid5 sdd1[3] sdc1[1] sdb1[0]
+      2095104 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [UU_]
+      [==>..................]  recovery = 12.6% (132096/1047552) finish=0.4min speed=33024K/sec
+
+unused devices: <none>
+`,
+			want: []mdArray{{
+				Name: "md0", State: "active", Level: "raid5",
+				Members: 3, Disks: 3, Working: 2,
+				Sync: "recovery", Progress: 12.6, Finish: 24 * time.Second,
+			}},
+			status: []string{"recovery 12.6%, ~24s left"},
+		},
+		{
+			name: "resync delayed",
+			input: `Personalities : [raid1]
+md1 : active raid1 sdb2[1] sda2[0]
+      1953382400 blocks super 1.2 [2/2] [UU]
+      	resync=DELAYED
+
+md0 : active raid1 sdb1[1] sda1[0]
+      1048512 blocks super 1.2 [2/2] [UU]
+      [=======>.............]  resync = 38.0% (398464/1048512) finish=1.5min speed=7100K/sec
+
+unused devices: <none>
+`,
+			want: []mdArray{
+				{
+					Name: "md1", State: "active", Level: "raid1",
+					Members: 2, Disks: 2, Working: 2,
+					Sync: "resync", Pending: true,
+				},
+				{
+					Name: "md0", State: "active", Level: "raid1",
+					Members: 2, Disks: 2, Working: 2,
+					Sync: "resync", Progress: 38, Finish: 90 * time.Second,
+				},
+			},
+			status: []string{"resync pending", "resync 38.0%, ~1m left"},
+		},
+		{
+			// Arrays without redundancy have no member counts line
+			name: "raid0 and linear",
+			input: `Personalities : [raid0] [linear]
+md2 : active raid0 sdf1[1] sde1[0]
+      1953260544 blocks super 1.2 512k chunks
+
+md3 : active linear sdh1[1] sdg1[0]
+      976506880 blocks super 1.2 0k rounding
+
+unused devices: <none>
+`,
+			want: []mdArray{
+				{Name: "md2", State: "active", Level: "raid0", Members: 2, Working: 2},
+				{Name: "md3", State: "active", Level: "linear", Members: 2, Working: 2},
+			},
+			status: []string{"healthy", "healthy"},
+		},
+		{
+			name: "inactive with spare",
+			input: `Personalities :
+md127 : inactive sdb[1](S) sda[0](S)
+      3906766976 blocks super 1.2
+
+unused devices: <none>
+`,
+			want: []mdArray{
+				{Name: "md127", State: "inactive", Members: 2, Spares: 2},
+			},
+			status: []string{"inactive"},
+		},
+		{
+			name:  "no arrays",
+			input: "Personalities :\nunused devices: <none>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMDStat(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseMDStat() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+			for i, a := range got {
+				if status := a.Status(); status != tt.status[i] {
+					t.Errorf("%s status = %q, want %q", a.Name, status, tt.status[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import "time"
+
+// sampleElapsed returns the time between two counter samples taken at prev
+// and now. It reports false when rates shouldn't be computed across them:
+// when there is no previous sample, and when the machine was suspended in
+// between. The monotonic clock stops while suspended and the wall clock
+// doesn't, so a gap between them means the counters span a suspend, and a
+// rate over it would be averaged over time the counters never saw.
+func sampleElapsed(prev, now time.Time) (time.Duration, bool) {
+	if prev.IsZero() {
+		return 0, false
+	}
+	return checkElapsed(now.Sub(prev), now.Round(0).Sub(prev.Round(0)))
+}
+
+// checkElapsed applies sampleElapsed's checks to the monotonic and wall
+// clock time between two samples
+func checkElapsed(elapsed, wall time.Duration) (time.Duration, bool) {
+	if elapsed <= 0 || wall-elapsed > time.Second {
+		return 0, false
+	}
+	return elapsed, true
+}
+
+// counterRate returns the per-second rate of a counter that went from prev
+// to cur over elapsed. It reports false when the counter went backwards, as
+// it does when an interface is recreated, a driver is reloaded, or the
+// counter wraps, so the sample can be skipped instead of showing an
+// enormous rate.
+func counterRate(prev, cur uint64, elapsed time.Duration) (float64, bool) {
+	if cur < prev || elapsed <= 0 {
+		return 0, false
+	}
+	return float64(cur-prev) / elapsed.Seconds(), true
+}
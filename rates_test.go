@@ -0,0 +1,121 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSampleElapsed(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		prev, now time.Time
+		want      time.Duration
+		ok        bool
+	}{
+		{"first sample", time.Time{}, now, 0, false},
+		{"one second", now, now.Add(time.Second), time.Second, true},
+		{"same instant", now, now, 0, false},
+		{"backwards", now, now.Add(-time.Second), 0, false},
+		// Without monotonic readings both clocks are the wall clock
+		{"wall clock only", now.Round(0), now.Add(2 * time.Second).Round(0), 2 * time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sampleElapsed(tt.prev, tt.now)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("sampleElapsed() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// rateSample is one counter reading, taken monotonic and wall time after
+// the previous one
+type rateSample struct {
+	monotonic, wall time.Duration
+	counter         uint64
+}
+
+func TestCounterRateSequences(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []rateSample
+		want    []float64 // Rate after each sample but the first, -1 when skipped
+	}{
+		{
+			name: "steady",
+			samples: []rateSample{
+				{0, 0, 1000},
+				{time.Second, time.Second, 1500},
+				{time.Second, time.Second, 2000},
+			},
+			want: []float64{500, 500},
+		},
+		{
+			name: "32-bit wrap",
+			samples: []rateSample{
+				{0, 0, 1<<32 - 300},
+				{time.Second, time.Second, 1<<32 - 100},
+				{time.Second, time.Second, 100},
+				{time.Second, time.Second, 400},
+			},
+			want: []float64{200, -1, 300},
+		},
+		{
+			name: "reset to zero",
+			samples: []rateSample{
+				{0, 0, 5_000_000},
+				{time.Second, time.Second, 0},
+				{time.Second, time.Second, 800},
+			},
+			want: []float64{-1, 800},
+		},
+		{
+			name: "suspend gap",
+			samples: []rateSample{
+				{0, 0, 1000},
+				{time.Second, time.Second, 2000},
+				// Suspended for an hour, which only the wall clock saw
+				{time.Second, time.Hour + time.Second, 500_000},
+				{time.Second, time.Second, 501_000},
+			},
+			want: []float64{1000, -1, 1000},
+		},
+		{
+			name: "clock stepped back",
+			samples: []rateSample{
+				{0, 0, 1000},
+				{2 * time.Second, -time.Minute, 3000},
+			},
+			want: []float64{1000},
+		},
+		{
+			name: "wall clock jitter",
+			samples: []rateSample{
+				{0, 0, 1000},
+				{2 * time.Second, 2*time.Second + 500*time.Millisecond, 3000},
+			},
+			want: []float64{1000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []float64
+			for i := 1; i < len(tt.samples); i++ {
+				prev, cur := tt.samples[i-1], tt.samples[i]
+				rate := -1.0
+				if elapsed, ok := checkElapsed(cur.monotonic, cur.wall); ok {
+					if r, ok := counterRate(prev.counter, cur.counter, elapsed); ok {
+						rate = r
+					}
+				}
+				got = append(got, rate)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("rates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
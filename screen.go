@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// layoutMode is how much of the display the terminal has room for
+type layoutMode int
+
+const (
+	layoutFull     layoutMode = iota // Every shown section, graphs included
+	layoutCompact                    // Header, average CPU gauge, rate lines, and the process list
+	layoutTooSmall                   // Only a notice asking for a bigger terminal
+)
+
+// The full layout needs fullMinWidth columns and room for every shown
+// section with its graphs squeezed to minGraphHeight rows. The compact
+// layout needs minTermWidth by minTermHeight; below that only a notice is
+// shown.
+const (
+	fullMinWidth   = 80
+	minGraphHeight = 4 // Border and two rows of braille
+	minTermWidth   = 60
+	minTermHeight  = 16
+)
+
+// placed holds the widgets the current layout has positioned. render skips
+// the rest, so updates to hidden sections, or to widgets the compact layout
+// leaves out, don't draw over the ones that are shown.
+var placed = make(map[ui.Drawable]bool)
+
+// place marks items as positioned by the current layout
+func place(items ...ui.Drawable) {
+	for _, item := range items {
+		placed[item] = true
+	}
+}
+
+// pending holds the widgets to draw at the next flush, in the order they
+// were last asked for
+var pending []ui.Drawable
+
+// render queues whichever of items the current layout placed for the next
+// flush. A widget queued again moves to the back, so it's drawn over those
+// queued before it, just as if each had been drawn straight away.
+func render(items ...ui.Drawable) {
+	for _, item := range items {
+		if !placed[item] {
+			continue
+		}
+		pending = slices.DeleteFunc(pending, func(p ui.Drawable) bool { return p == item })
+		pending = append(pending, item)
+	}
+}
+
+// flush draws the queued widgets in a single ui.Render. Each ui.Render call
+// writes to the terminal, so drawing widgets one by one made them change at
+// slightly different moments and rewrote cells that a later widget, or a
+// redraw's clear, changed again straight after.
+func flush() {
+	if len(pending) == 0 {
+		return
+	}
+	ui.Render(pending...)
+	pending = pending[:0]
+}
+
+// shrinkGraphs takes excess rows back from the graphs' heights, evenly but
+// never below minGraphHeight, the first graphs giving up any remainder
+func shrinkGraphs(excess int, heights ...*int) {
+	for excess > 0 {
+		shrunk := false
+		for _, h := range heights {
+			if excess > 0 && *h > minGraphHeight {
+				*h--
+				excess--
+				shrunk = true
+			}
+		}
+		if !shrunk {
+			return
+		}
+	}
+}
+
+func createTooSmallNotice() *widgets.Paragraph {
+	p := widgets.NewParagraph()
+	p.Border = false
+	p.Text = fmt.Sprintf("[Terminal too small (need %dx%d)](fg:warn)", minTermWidth, minTermHeight)
+	return p
+}
+
+// createCompactLine returns one of the compact layout's rate lines, which
+// stand in for the network and disk sections
+func createCompactLine() *widgets.Paragraph {
+	p := widgets.NewParagraph()
+	p.Border = false
+	return p
+}
+
+// compactNetText summarizes the network section for the compact layout
+func compactNetText(traffic netTotals, unit NetUnit) string {
+	return fmt.Sprintf("[Net ](fg:label) [In:](fg:rx) %s  [Out:](fg:tx) %s",
+		unit.Format(traffic.RxRate), unit.Format(traffic.TxRate))
+}
+
+// compactDiskText summarizes the disk section for the compact layout
+func compactDiskText(rates diskDeviceRates) string {
+	return fmt.Sprintf("[Disk](fg:label) [R:](fg:read) %.2f MB/s  [W:](fg:write) %.2f MB/s",
+		rates.ReadMBps, rates.WriteMBps)
+}
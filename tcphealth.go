@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kernel TCP counters on Linux. Both files hold pairs of lines, a header
+// naming the counters and a line of values, each starting with the same
+// prefix such as "Tcp:".
+const (
+	procNetSNMP    = "/proc/net/snmp"
+	procNetNetstat = "/proc/net/netstat"
+)
+
+// retransWarnRatio is the share of outbound segments retransmitted above
+// which the rate is shown in red. A healthy network retransmits well under
+// 1% of segments.
+const retransWarnRatio = 0.01
+
+// parseProcNetStats parses the paired header and value lines of
+// /proc/net/snmp or /proc/net/netstat into counters by prefix and name,
+// e.g. stats["Tcp"]["RetransSegs"]. Counters that aren't unsigned, such as
+// Tcp's MaxConn of -1, are left out.
+func parseProcNetStats(r io.Reader) (map[string]map[string]uint64, error) {
+	stats := make(map[string]map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		header := strings.Fields(scanner.Text())
+		if !scanner.Scan() {
+			break
+		}
+		values := strings.Fields(scanner.Text())
+		if len(header) == 0 || len(values) != len(header) || header[0] != values[0] {
+			return nil, fmt.Errorf("mismatched lines for %q", strings.Join(header, " "))
+		}
+		prefix := strings.TrimSuffix(header[0], ":")
+		counters := make(map[string]uint64, len(header)-1)
+		for i := 1; i < len(header); i++ {
+			if v, err := strconv.ParseUint(values[i], 10, 64); err == nil {
+				counters[header[i]] = v
+			}
+		}
+		stats[prefix] = counters
+	}
+	return stats, scanner.Err()
+}
+
+// readProcNetStats reads and parses one of the /proc/net counter files
+func readProcNetStats(path string) (map[string]map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseProcNetStats(f)
+}
+
+// tcpCounters are the kernel's TCP counters since boot
+type tcpCounters struct {
+	OutSegs     uint64 // Segments sent, including retransmissions
+	RetransSegs uint64 // Segments retransmitted
+	OutRsts     uint64 // Resets sent
+	EstabResets uint64 // Established connections reset
+	Timeouts    uint64 // Retransmission timeouts, from TcpExt
+}
+
+// readTCPCounters reads the TCP counters from /proc/net/snmp, and the
+// retransmission timeouts from /proc/net/netstat when it is readable
+func readTCPCounters() (tcpCounters, error) {
+	snmp, err := readProcNetStats(procNetSNMP)
+	if err != nil {
+		return tcpCounters{}, err
+	}
+	tcp := snmp["Tcp"]
+	c := tcpCounters{
+		OutSegs:     tcp["OutSegs"],
+		RetransSegs: tcp["RetransSegs"],
+		OutRsts:     tcp["OutRsts"],
+		EstabResets: tcp["EstabResets"],
+	}
+	if netstat, err := readProcNetStats(procNetNetstat); err == nil {
+		c.Timeouts = netstat["TcpExt"]["TCPTimeouts"]
+	}
+	return c, nil
+}
+
+// TCPHealth turns the kernel's TCP counters into the rates shown on the
+// network section's health line, telling a lossy network from a slow one.
+// The counters are only available on Linux; elsewhere Available is false
+// and the line is hidden.
+type TCPHealth struct {
+	Available bool
+
+	RetransRate  float64 // Segments retransmitted per second
+	RetransRatio float64 // Retransmitted segments as a share of all sent
+	TimeoutRate  float64 // Retransmission timeouts per second
+	ResetRate    float64 // Resets sent and connections reset per second
+
+	prev tcpCounters // Counters at the last sample
+	last time.Time   // When the last sample was taken, or zero before the first
+}
+
+func newTCPHealth() *TCPHealth {
+	h := &TCPHealth{}
+	if c, err := readTCPCounters(); err == nil {
+		h.Available = true
+		h.prev = c
+		h.last = time.Now()
+	}
+	return h
+}
+
+// Update takes a new sample of the counters. Rates stay at zero across a
+// suspend or a counter going backwards.
+func (h *TCPHealth) Update() {
+	if !h.Available {
+		return
+	}
+	c, err := readTCPCounters()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	elapsed, valid := sampleElapsed(h.last, now)
+	h.RetransRate, h.RetransRatio, h.TimeoutRate, h.ResetRate = 0, 0, 0, 0
+	if valid {
+		sent, okSent := counterRate(h.prev.OutSegs, c.OutSegs, elapsed)
+		retrans, okRetrans := counterRate(h.prev.RetransSegs, c.RetransSegs, elapsed)
+		if okSent && okRetrans {
+			h.RetransRate = retrans
+			if sent > 0 {
+				h.RetransRatio = retrans / sent
+			}
+		}
+		h.TimeoutRate, _ = counterRate(h.prev.Timeouts, c.Timeouts, elapsed)
+		outRsts, _ := counterRate(h.prev.OutRsts, c.OutRsts, elapsed)
+		estabResets, _ := counterRate(h.prev.EstabResets, c.EstabResets, elapsed)
+		h.ResetRate = outRsts + estabResets
+	}
+	h.prev = c
+	h.last = now
+}
+
+// Text renders the rates, with retransmissions in red above
+// retransWarnRatio of the segments sent
+func (h *TCPHealth) Text() string {
+	retrans := fmt.Sprintf("%s (%.1f%%)", formatEventRate(h.RetransRate), h.RetransRatio*100)
+	if h.RetransRatio > retransWarnRatio {
+		retrans = fmt.Sprintf("[%s](fg:crit)", retrans)
+	}
+	return fmt.Sprintf("[Retrans:](fg:label) %s  [Timeouts:](fg:label) %s  [Resets:](fg:label) %s",
+		retrans, formatEventRate(h.TimeoutRate), formatEventRate(h.ResetRate))
+}
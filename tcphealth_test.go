@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sampleSNMP is trimmed from a Linux 6.x /proc/net/snmp
+const sampleSNMP = `Ip: Forwarding DefaultTTL InReceives InHdrErrors
+Ip: 1 64 2812741 0
+Icmp: InMsgs InErrors
+Icmp: 45 2
+Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens PassiveOpens AttemptFails EstabResets CurrEstab InSegs OutSegs RetransSegs InErrs OutRsts InCsumErrors
+Tcp: 1 200 120000 -1 10514 1209 1540 811 12 2694418 2905733 3302 1 4211 0
+Udp: InDatagrams NoPorts InErrors OutDatagrams
+Udp: 112548 403 0 113022
+`
+
+// sampleNetstat is trimmed from a Linux 6.x /proc/net/netstat
+const sampleNetstat = `TcpExt: SyncookiesSent SyncookiesRecv TCPTimeouts TCPLossProbes
+TcpExt: 0 0 187 1201
+IpExt: InNoRoutes InTruncatedPkts InOctets OutOctets
+IpExt: 0 0 3392488542 412207718
+`
+
+func TestParseProcNetStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]map[string]uint64
+		wantErr bool
+	}{
+		{
+			name:  "snmp",
+			input: sampleSNMP,
+			want: map[string]map[string]uint64{
+				"Ip":   {"Forwarding": 1, "DefaultTTL": 64, "InReceives": 2812741, "InHdrErrors": 0},
+				"Icmp": {"InMsgs": 45, "InErrors": 2},
+				// MaxConn is -1, meaning no limit, and is left out
+				"Tcp": {
+					"RtoAlgorithm": 1, "RtoMin": 200, "RtoMax": 120000,
+					"ActiveOpens": 10514, "PassiveOpens": 1209, "AttemptFails": 1540,
+					"EstabResets": 811, "CurrEstab": 12, "InSegs": 2694418,
+					"OutSegs": 2905733, "RetransSegs": 3302, "InErrs": 1,
+					"OutRsts": 4211, "InCsumErrors": 0,
+				},
+				"Udp": {"InDatagrams": 112548, "NoPorts": 403, "InErrors": 0, "OutDatagrams": 113022},
+			},
+		},
+		{
+			name:  "netstat",
+			input: sampleNetstat,
+			want: map[string]map[string]uint64{
+				"TcpExt": {"SyncookiesSent": 0, "SyncookiesRecv": 0, "TCPTimeouts": 187, "TCPLossProbes": 1201},
+				"IpExt":  {"InNoRoutes": 0, "InTruncatedPkts": 0, "InOctets": 3392488542, "OutOctets": 412207718},
+			},
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  map[string]map[string]uint64{},
+		},
+		{
+			name:    "fewer values than headers",
+			input:   "Tcp: RtoAlgorithm RtoMin RtoMax\nTcp: 1 200\n",
+			wantErr: true,
+		},
+		{
+			name:    "more values than headers",
+			input:   "Tcp: RtoAlgorithm RtoMin\nTcp: 1 200 120000\n",
+			wantErr: true,
+		},
+		{
+			name:    "mismatched prefix",
+			input:   "Tcp: RtoAlgorithm RtoMin\nUdp: 1 200\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProcNetStats(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseProcNetStats() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseProcNetStats() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseProcNetStats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
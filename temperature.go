@@ -0,0 +1,50 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// defaultTempWarning is the CPU temperature in °C above which the readout
+// turns red, unless --temp-warn says otherwise
+const defaultTempWarning = 85.0
+
+// cpuSensorPattern matches the sensor keys of CPU package and core
+// temperatures: coretemp on Intel, k10temp and zenpower on AMD, and the
+// generic names used by macOS, the BSDs, and ACPI thermal zones
+var cpuSensorPattern = regexp.MustCompile(`(?i)coretemp|k10temp|zenpower|tctl|tdie|package|core|cpu`)
+
+// cpuTemperature returns the hottest CPU sensor reading in °C, and false
+// when the machine exposes no CPU sensors (VMs, some laptops). Reading
+// sensors is slow on some platforms, so callers shouldn't do it every tick.
+func cpuTemperature() (float64, bool) {
+	// Some sensors failing still returns the rest alongside the error
+	temps, _ := host.SensorsTemperatures()
+	hottest, found := 0.0, false
+	for _, t := range temps {
+		if !cpuSensorPattern.MatchString(t.SensorKey) || t.Temperature <= 0 {
+			continue
+		}
+		if !found || t.Temperature > hottest {
+			hottest, found = t.Temperature, true
+		}
+	}
+	return hottest, found
+}
+
+// temperatureReading is the outcome of a cpuTemperature call made off the
+// UI goroutine
+type temperatureReading struct {
+	Celsius float64
+	OK      bool
+}
+
+// readTemperature reads the CPU temperature on a goroutine and delivers it
+// on results
+func readTemperature(results chan<- temperatureReading) {
+	go func() {
+		celsius, ok := cpuTemperature()
+		results <- temperatureReading{Celsius: celsius, OK: ok}
+	}()
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+// Theme maps what each part of the display means to the color it is drawn
+// in. Inline markup names the roles rather than colors, e.g.
+// "[Load:](fg:label)", and install registers each role's color under that
+// name, so markup follows the theme like the widget styles do.
+type Theme struct {
+	Text     ui.Color   // Body text; markup "text"
+	Title    ui.Color   // Widget titles
+	Border   ui.Color   // Borders of the main sections
+	Frame    ui.Color   // Borders of the CPU core and memory gauges
+	Panel    ui.Color   // Borders of the panels drawn over other sections
+	Label    ui.Color   // Field labels and table headings; markup "label"
+	Accent   ui.Color   // Device and key names; markup "accent"
+	Dim      ui.Color   // Rows listed without counting, e.g. excluded interfaces; markup "dim"
+	Ok       ui.Color   // Readings below their warning level, and successes; markup "ok"
+	Warn     ui.Color   // Readings past their warning level, and notices; markup "warn"
+	Crit     ui.Color   // Readings past their critical level, and failures; markup "crit"
+	CPUAvg   ui.Color   // Average CPU utilization line; markup "cpu"
+	CPUPeak  ui.Color   // Busiest core line
+	Rx       ui.Color   // Received network traffic; markup "rx"
+	Tx       ui.Color   // Sent network traffic; markup "tx"
+	Read     ui.Color   // Disk reads; markup "read"
+	Write    ui.Color   // Disk writes; markup "write"
+	MemUsed  ui.Color   // Used memory line; markup "mem"
+	MemAvail ui.Color   // Available memory line
+	Line     ui.Color   // Graphs with a single line, such as free space
+	Heat     []ui.Color // Eleven CPU heatmap shades from idle to busy; see Heatmap.color
+}
+
+// themeNames are the --theme values, indexed like builtinThemes
+var themeNames = []string{"default", "light", "monochrome", "colorblind-safe"}
+
+var builtinThemes = []Theme{
+	// default suits dark terminals
+	{
+		Text: ui.ColorWhite, Title: ui.ColorWhite, Border: ui.ColorWhite,
+		Frame: ui.ColorBlue, Panel: ui.ColorCyan, Label: ui.ColorCyan,
+		Accent: ui.ColorYellow, Dim: 8,
+		Ok: ui.ColorGreen, Warn: ui.ColorYellow, Crit: ui.ColorRed,
+		CPUAvg: ui.ColorGreen, CPUPeak: ui.ColorYellow,
+		Rx: ui.ColorGreen, Tx: ui.ColorBlue,
+		Read: ui.ColorGreen, Write: ui.ColorRed,
+		MemUsed: ui.ColorMagenta, MemAvail: ui.ColorGreen,
+		Line: ui.ColorCyan,
+		Heat: []ui.Color{46, 82, 118, 154, 190, 226, 220, 214, 208, 202, 196},
+	},
+	// light uses darker shades from the 256-color palette, since white and
+	// yellow vanish on a light background
+	{
+		Text: ui.ColorBlack, Title: ui.ColorBlack, Border: 240,
+		Frame: 25, Panel: 25, Label: 25,
+		Accent: 130, Dim: 246,
+		Ok: 28, Warn: 130, Crit: 160,
+		CPUAvg: 28, CPUPeak: 130,
+		Rx: 28, Tx: 25,
+		Read: 28, Write: 160,
+		MemUsed: 90, MemAvail: 28,
+		Line: 25,
+		Heat: []ui.Color{28, 34, 70, 106, 142, 178, 172, 166, 160, 124, 88},
+	},
+	// monochrome draws text in the terminal's own foreground color and
+	// tells lines apart by brightness alone
+	{
+		Text: ui.ColorClear, Title: ui.ColorClear, Border: ui.ColorClear,
+		Frame: ui.ColorClear, Panel: ui.ColorClear, Label: ui.ColorClear,
+		Accent: ui.ColorClear, Dim: 8,
+		Ok: ui.ColorWhite, Warn: ui.ColorWhite, Crit: ui.ColorWhite,
+		CPUAvg: ui.ColorWhite, CPUPeak: 8,
+		Rx: ui.ColorWhite, Tx: 8,
+		Read: ui.ColorWhite, Write: 8,
+		MemUsed: ui.ColorWhite, MemAvail: 8,
+		Line: ui.ColorWhite,
+		Heat: []ui.Color{236, 238, 240, 242, 244, 246, 248, 250, 252, 254, 231},
+	},
+	// colorblind-safe avoids pairing red with green, using blue for good and
+	// yellow through orange for bad, after the Okabe-Ito palette
+	{
+		Text: ui.ColorWhite, Title: ui.ColorWhite, Border: ui.ColorWhite,
+		Frame: 32, Panel: 75, Label: 75,
+		Accent: 220, Dim: 8,
+		Ok: 39, Warn: 220, Crit: 202,
+		CPUAvg: 39, CPUPeak: 220,
+		Rx: 39, Tx: 214,
+		Read: 39, Write: 214,
+		MemUsed: 175, MemAvail: 39,
+		Line: 75,
+		Heat: []ui.Color{24, 25, 31, 32, 38, 227, 221, 215, 214, 208, 202},
+	},
+}
+
+// theme is the theme in use, chosen with --theme before any widget is
+// created
+var theme = builtinThemes[0]
+
+// parseTheme returns the builtin theme called name
+func parseTheme(name string) (Theme, error) {
+	for i, n := range themeNames {
+		if strings.EqualFold(name, n) {
+			return builtinThemes[i], nil
+		}
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q (valid themes: %s)", name, strings.Join(themeNames, ", "))
+}
+
+// install registers the theme's markup role names and makes its colors the
+// defaults for widgets that don't set their own. It must run before any
+// widget is created.
+func (t Theme) install() {
+	roles := map[string]ui.Color{
+		"text":   t.Text,
+		"label":  t.Label,
+		"accent": t.Accent,
+		"dim":    t.Dim,
+		"ok":     t.Ok,
+		"warn":   t.Warn,
+		"crit":   t.Crit,
+		"rx":     t.Rx,
+		"tx":     t.Tx,
+		"read":   t.Read,
+		"write":  t.Write,
+		"mem":    t.MemUsed,
+		"cpu":    t.CPUAvg,
+	}
+	for name, color := range roles {
+		ui.StyleParserColorMap[name] = color
+	}
+
+	ui.Theme.Default = ui.NewStyle(t.Text)
+	ui.Theme.Block.Title = ui.NewStyle(t.Title)
+	ui.Theme.Block.Border = ui.NewStyle(t.Border)
+	ui.Theme.Paragraph.Text = ui.NewStyle(t.Text)
+	ui.Theme.List.Text = ui.NewStyle(t.Text)
+	ui.Theme.Table.Text = ui.NewStyle(t.Text)
+	ui.Theme.Gauge.Label = ui.NewStyle(t.Text)
+	ui.Theme.Sparkline.Title = ui.NewStyle(t.Title)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// coreGroup is a set of logical cores shown together under a heading, such
+// as one physical socket or the efficiency cores of a hybrid CPU
+type coreGroup struct {
+	Name  string
+	Cores []int // Zero-based logical core numbers, in order
+}
+
+// cpuTopology groups count logical cores by core type on hybrid CPUs, or
+// else by physical socket. It returns nil when there is only one group or
+// the topology can't be determined, in which case the cores are shown flat.
+// It is slow enough on some platforms to be called once at startup.
+func cpuTopology(count int) []coreGroup {
+	groups := hybridGroups(count)
+	if len(groups) < 2 {
+		groups = socketGroups(count)
+	}
+	if len(groups) < 2 || !coversCores(groups, count) {
+		return nil
+	}
+	return groups
+}
+
+// hybridGroups splits the cores of Intel hybrid CPUs on Linux, which list
+// them under separate cpu_core and cpu_atom PMUs, and of Apple Silicon
+func hybridGroups(count int) []coreGroup {
+	switch runtime.GOOS {
+	case "linux":
+		pCores, err := readCPUList("/sys/devices/cpu_core/cpus")
+		if err != nil {
+			return nil
+		}
+		eCores, err := readCPUList("/sys/devices/cpu_atom/cpus")
+		if err != nil {
+			return nil
+		}
+		return []coreGroup{{Name: "P-cores", Cores: pCores}, {Name: "E-cores", Cores: eCores}}
+	case "darwin":
+		// perflevel0 is the performance cluster and perflevel1 the
+		// efficiency one; macOS numbers the efficiency cores first
+		pCount := sysctlInt("hw.perflevel0.logicalcpu")
+		eCount := sysctlInt("hw.perflevel1.logicalcpu")
+		if pCount <= 0 || eCount <= 0 || pCount+eCount != count {
+			return nil
+		}
+		return []coreGroup{
+			{Name: "P-cores", Cores: coreRange(eCount, count)},
+			{Name: "E-cores", Cores: coreRange(0, eCount)},
+		}
+	}
+	return nil
+}
+
+// socketGroups splits the cores by the physical package cpu.Info reports
+// them in. Only Linux reports one entry per logical core.
+func socketGroups(count int) []coreGroup {
+	infos, err := cpu.Info()
+	if err != nil || len(infos) != count {
+		return nil
+	}
+	bySocket := make(map[string][]int)
+	for _, info := range infos {
+		bySocket[info.PhysicalID] = append(bySocket[info.PhysicalID], int(info.CPU))
+	}
+	sockets := make([]string, 0, len(bySocket))
+	for id := range bySocket {
+		sockets = append(sockets, id)
+	}
+	sort.Slice(sockets, func(i, j int) bool {
+		a, errA := strconv.Atoi(sockets[i])
+		b, errB := strconv.Atoi(sockets[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return sockets[i] < sockets[j]
+	})
+
+	groups := make([]coreGroup, len(sockets))
+	for i, id := range sockets {
+		cores := bySocket[id]
+		sort.Ints(cores)
+		groups[i] = coreGroup{Name: "Socket " + id, Cores: cores}
+	}
+	return groups
+}
+
+// coversCores reports whether groups hold each of count cores exactly once
+func coversCores(groups []coreGroup, count int) bool {
+	seen := make([]bool, count)
+	total := 0
+	for _, g := range groups {
+		for _, core := range g.Cores {
+			if core < 0 || core >= count || seen[core] {
+				return false
+			}
+			seen[core] = true
+			total++
+		}
+	}
+	return total == count
+}
+
+// readCPUList parses a kernel CPU list file such as "0-7,16-23"
+func readCPUList(path string) ([]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cores []int
+	for _, part := range strings.Split(strings.TrimSpace(string(data)), ",") {
+		if part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		lo, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("bad CPU list %q", data)
+		}
+		hi := lo
+		if isRange {
+			if hi, err = strconv.Atoi(last); err != nil {
+				return nil, fmt.Errorf("bad CPU list %q", data)
+			}
+		}
+		cores = append(cores, coreRange(lo, hi+1)...)
+	}
+	return cores, nil
+}
+
+// coreRange returns the core numbers from lo up to but not including hi
+func coreRange(lo, hi int) []int {
+	cores := make([]int, 0, hi-lo)
+	for i := lo; i < hi; i++ {
+		cores = append(cores, i)
+	}
+	return cores
+}
+
+// sysctlInt reads a numeric sysctl on macOS, returning -1 when it's missing
+func sysctlInt(name string) int {
+	out, err := exec.Command("sysctl", "-n", name).Output()
+	if err != nil {
+		return -1
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return -1
+	}
+	return n
+}